@@ -0,0 +1,135 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package benchmarks
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/encoding"
+	"github.com/xybor-x/xylog/test"
+)
+
+// fullMacros contains names of macros added by test.AddFullMacros.
+var fullMacros = []string{
+	"asctime", "created", "createdNanos", "filename", "funcname", "goroutine",
+	"levelname", "levelno", "lineno", "module", "msecs", "pathname", "process",
+	"relativeCreated", "sequence",
+}
+
+// legacyRecord is a LogRecord carrying the textual time, as LogRecord did
+// before Handlers formatted the time themselves.
+type legacyRecord struct {
+	xylog.LogRecord
+	Asctime string
+}
+
+// getValue resolves an attribute of the record by its name, as Handlers did
+// for every macro of every record before macros were compiled.
+func (r legacyRecord) getValue(name string) (any, error) {
+	switch name {
+	case "asctime":
+		return r.Asctime, nil
+	case "created":
+		return r.Created, nil
+	case "createdNanos":
+		return r.Time.UnixNano(), nil
+	case "filename":
+		return r.FileName, nil
+	case "funcname":
+		return r.FuncName, nil
+	case "goroutine":
+		return r.GoroutineID, nil
+	case "levelname":
+		return r.LevelName, nil
+	case "levelno":
+		return r.LevelNo, nil
+	case "lineno":
+		return r.LineNo, nil
+	case "module":
+		return r.Module, nil
+	case "msecs":
+		return r.Msecs, nil
+	case "pathname":
+		return r.PathName, nil
+	case "process":
+		return r.Process, nil
+	case "relativeCreated":
+		return r.RelativeCreated, nil
+	case "sequence":
+		return r.Sequence, nil
+	default:
+		return nil, xyerror.ValueError.Newf("not found attribute %s", name)
+	}
+}
+
+// legacyHandler formats records in the same way as Handler did before macros
+// were compiled. Unlike Handler, it does not check levels and filters.
+type legacyHandler struct {
+	encoder *encoding.Encoder
+	macros  []string
+	emitter xylog.Emitter
+}
+
+func (h *legacyHandler) handle(record legacyRecord) {
+	var encoder = h.encoder.Clone()
+	defer encoder.Free()
+
+	for _, macro := range h.macros {
+		var attr, err = record.getValue(macro)
+		if err != nil {
+			return
+		}
+		encoder.Add(macro, attr)
+	}
+	h.emitter.Emit(encoder.Encode())
+}
+
+func BenchmarkHandlerFullMacros(b *testing.B) {
+	var handler = xylog.GetHandler("")
+	handler.AddEmitter(xylog.NewBufferEmitter(io.Discard, 4096))
+	test.AddFullMacros(handler)
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			handler.Handle(test.FullRecord)
+		}
+	})
+}
+
+func BenchmarkLegacyHandlerFullMacros(b *testing.B) {
+	var handler = &legacyHandler{
+		encoder: encoding.NewEncoder(encoding.NewTextEncoding()),
+		macros:  fullMacros,
+		emitter: xylog.NewBufferEmitter(io.Discard, 4096),
+	}
+	var record = legacyRecord{
+		LogRecord: test.FullRecord,
+		Asctime:   test.FullRecord.Time.Format(time.RFC3339Nano),
+	}
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			handler.handle(record)
+		}
+	})
+}
@@ -102,3 +102,60 @@ func BenchmarkAddingFields(b *testing.B) {
 		})
 	})
 }
+
+func BenchmarkDerivedLogger(b *testing.B) {
+	test.WithBenchLogger(b, func(logger *xylog.Logger) {
+		logger.SetLevel(xylog.DEBUG)
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				logger.With("request_id", _tenInts[0], "user", _oneUser).
+					Debug(test.GetRandomMessage())
+			}
+		})
+	})
+}
+
+func BenchmarkDerivedLoggerAccumulatedContext(b *testing.B) {
+	test.WithBenchLogger(b, func(logger *xylog.Logger) {
+		logger.SetLevel(xylog.DEBUG)
+		var derived = logger.With(
+			"int", _tenInts[0],
+			"ints", _tenInts,
+			"string", _tenStrings[0],
+			"strings", _tenStrings,
+			"time", _tenTimes[0],
+			"times", _tenTimes,
+			"user1", _oneUser,
+			"user2", _oneUser,
+			"users", _tenUsers,
+			"error", errExample,
+		)
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				derived.Debug(test.GetRandomMessage())
+			}
+		})
+	})
+}
+
+func BenchmarkSugaredFields(b *testing.B) {
+	test.WithBenchLogger(b, func(logger *xylog.Logger) {
+		logger.SetLevel(xylog.DEBUG)
+		b.RunParallel(func(p *testing.PB) {
+			for p.Next() {
+				logger.Debugw(test.GetRandomMessage(),
+					"int", _tenInts[0],
+					"ints", _tenInts,
+					"string", _tenStrings[0],
+					"strings", _tenStrings,
+					"time", _tenTimes[0],
+					"times", _tenTimes,
+					"user1", _oneUser,
+					"user2", _oneUser,
+					"users", _tenUsers,
+					"error", errExample,
+				)
+			}
+		})
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import "time"
+
+// Clock provides the current time when creating LogRecords. Replace the
+// default system clock with SetClock to get deterministic timestamps.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// TimerClock is a Clock which also schedules functions. If the Clock of a
+// Registry is a TimerClock, temporary levels set by Logger.SetLevelFor expire
+// by its time, otherwise they expire by the system time.
+type TimerClock interface {
+	Clock
+
+	// AfterFunc calls f after the duration elapses. The returned function
+	// cancels the call, it returns false if f has already been called.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// systemClock is a Clock which uses time.Now.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc calls f in its own goroutine after the duration elapses.
+func (systemClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// afterFunc calls f after the duration elapses on the Clock if it is a
+// TimerClock, or on the system clock otherwise.
+func afterFunc(c Clock, d time.Duration, f func()) func() bool {
+	if tc, ok := c.(TimerClock); ok {
+		return tc.AfterFunc(d, f)
+	}
+	return systemClock{}.AfterFunc(d, f)
+}
+
+// SetClock sets the Clock used to create LogRecords. The relative creation time
+// of records is measured from this call. Use nil to restore the system clock.
+func SetClock(c Clock) {
+	defaultRegistry.SetClock(c)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func TestSetClock(t *testing.T) {
+	var start = time.Date(2023, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	test.WithFakeClock(start, func(c *test.FakeClock) {
+		test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+			var handler = logger.Handlers()[0]
+			handler.AddMacro("asctime", "asctime")
+			handler.AddMacro("created", "created")
+			handler.AddMacro("msecs", "msecs")
+			handler.AddMacro("relativeCreated", "relativeCreated")
+
+			logger.Error("foo")
+			xycond.ExpectEqual(w.Captured,
+				"asctime=2023-01-02T03:04:05.006Z created=1672628645 msecs=6 "+
+					"relativeCreated=0 messsage=foo\n").Test(t)
+
+			w.Reset()
+			c.Advance(1500 * time.Millisecond)
+			logger.Error("foo")
+			xycond.ExpectEqual(w.Captured,
+				"asctime=2023-01-02T03:04:06.506Z created=1672628646 "+
+					"msecs=506 relativeCreated=1500 messsage=foo\n").Test(t)
+		})
+	})
+}
+
+func TestSetClockNil(t *testing.T) {
+	xylog.SetClock(nil)
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.Handlers()[0].AddMacro("created", "created")
+		logger.Error("foo")
+		xycond.ExpectIn("created=", w.Captured).Test(t)
+		xycond.ExpectNotIn("created=1672628645", w.Captured).Test(t)
+	})
+}
@@ -31,11 +31,6 @@ import (
 	"github.com/xybor-x/xylog/encoding"
 )
 
-func init() {
-	rootLogger = newLogger("", nil)
-	rootLogger.SetLevel(WARNING)
-}
-
 // Default levels, these can be replaced with any positive set of values having
 // corresponding names. There is a pseudo-level, NOTSET, which is only really
 // there as a lower limit for user-defined levels. Handlers and loggers are
@@ -47,75 +42,73 @@ const (
 	ERROR    = 40
 	WARNING  = 30
 	WARN     = WARNING
+	NOTICE   = 25
 	INFO     = 20
 	DEBUG    = 10
+	TRACE    = 5
 	NOTSET   = 0
 )
 
-// startTime is used as the base when calculating the relative time of events.
-var startTime = time.Now().UnixMilli()
-
-// globalLock is used to serialize access to shared data structures in this
-// module.
+// globalLock is used to serialize access to data structures shared by all
+// Registries in this module.
 var globalLock = &xylock.RWLock{}
 
 // processid is always fixed and used to fill %(process) macro.
 var processid = os.Getpid()
 
-// timeLayout is the default time layout used to print asctime when logging.
-var timeLayout = time.RFC3339Nano
-
-// rootLogger is the parent Logger of all Loggers in program.
-var rootLogger *Logger
-
-// handlerManager is a map to search Handler by name.
-var handlerManager = make(map[string]*Handler)
-
-// emitterManager is a list containing all created Emitters in program.
-var emitterManager []Emitter
-
-// skipCall is the depth of Logger.log call in program, 3 by default. Increase
-// this value if you want to wrap log methods of logger.
-var skipCall = 3
+// generation is increased whenever the effective level or the Handler chain of
+// any Logger may change. Caches computed at an older generation are invalid.
+// It starts at 1, so the zero value of a cache is always invalid.
+var generation uint32 = 1
 
-// findCaller allows finding caller information including filename, lineno,
-// funcname, module.
-var findCaller = false
+// sequence is the sequence number of the latest created LogRecord.
+var sequence uint64
 
 var levelToName = map[int]string{
 	CRITICAL: "CRITICAL",
 	ERROR:    "ERROR",
 	WARNING:  "WARNING",
+	NOTICE:   "NOTICE",
 	INFO:     "INFO",
 	DEBUG:    "DEBUG",
+	TRACE:    "TRACE",
 	NOTSET:   "NOTSET",
 }
 
 // SetSkipCall sets the new skipCall value which dertermine the depth call of
 // Logger.log method.
 func SetSkipCall(skip int) {
-	globalLock.WLockFunc(func() { skipCall = skip })
+	defaultRegistry.SetSkipCall(skip)
 }
 
-// SetTimeLayout sets the time layout to print asctime. It is time.RFC3339Nano
-// by default.
+// SetTimeLayout sets the default time layout to print asctime. It only affects
+// Handlers created after this call, use Handler.SetTimeLayout to change the
+// layout of an existing Handler. It is time.RFC3339Nano by default.
 func SetTimeLayout(layout string) {
-	globalLock.WLockFunc(func() { timeLayout = layout })
+	defaultRegistry.SetTimeLayout(layout)
 }
 
 // SetFindCaller with true to find caller information including filename, line
 // number, function name, and module.
 func SetFindCaller(b bool) {
-	globalLock.WLockFunc(func() { findCaller = b })
+	defaultRegistry.SetFindCaller(b)
+}
+
+// SetFindGoroutine with true to find the ID of goroutine which logs the
+// record. It is disabled by default because it is expensive.
+func SetFindGoroutine(b bool) {
+	defaultRegistry.SetFindGoroutine(b)
 }
 
 // AddLevel associates a log level with name. It can overwrite other log levels.
 // Default log levels:
 //   NOTSET       0
+//   TRACE        5
 //   DEBUG        10
 //   INFO         20
+//   NOTICE       25
 //   WARN/WARNING 30
-//   ERROR/FATAL  40
+//   ERROR        40
 //   CRITICAL     50
 func AddLevel(level int, levelName string) {
 	globalLock.WLockFunc(func() { levelToName[level] = levelName })
@@ -138,12 +131,7 @@ func GetLevelName(level int) string {
 
 // Flush writes unflushed buffered data to outputs.
 func Flush() {
-	globalLock.RLock()
-	defer globalLock.RUnlock()
-
-	for i := range emitterManager {
-		emitterManager[i].Flush()
-	}
+	defaultRegistry.Flush()
 }
 
 // SimpleConfig supports to quickly create a Logger without configurating
@@ -153,6 +141,10 @@ type SimpleConfig struct {
 	// Default to an empty name (the root logger).
 	Name string
 
+	// The Registry which the Logger, Handler and Emitter belong to. Default to
+	// the default Registry.
+	Registry *Registry
+
 	// Use the specified encoding to format the output. Default to TextEncoding.
 	Encoding encoding.Encoding
 
@@ -172,6 +164,10 @@ type SimpleConfig struct {
 	// The time layout when format the time string. Default to RFC3339Nano.
 	TimeLayout string
 
+	// The timezone when format the time string. Default to the timezone of
+	// the logging time.
+	Timezone *time.Location
+
 	// Specify that Logger will write the output to a file. Do NOT use together
 	// with Filename.
 	Writer io.Writer
@@ -179,7 +175,8 @@ type SimpleConfig struct {
 	macros []macroField
 }
 
-// AddMacro adds a macro value to output format.
+// AddMacro adds a macro value to output format. An unknown macro is reported
+// by Apply.
 func (cfg *SimpleConfig) AddMacro(name, value string) *SimpleConfig {
 	cfg.macros = append(cfg.macros, macroField{key: name, macro: value})
 	return cfg
@@ -187,14 +184,40 @@ func (cfg *SimpleConfig) AddMacro(name, value string) *SimpleConfig {
 
 // Apply creates a Logger based on the configuration.
 func (cfg SimpleConfig) Apply() (*Logger, error) {
-	if cfg.TimeLayout != "" {
-		SetTimeLayout(cfg.TimeLayout)
-	}
-
 	if cfg.Filename != "" && cfg.Writer != nil {
 		return nil, xyerror.ParameterError.New("do not set both filename and writer")
 	}
 
+	var enc = cfg.Encoding
+	if enc == nil {
+		enc = encoding.NewTextEncoding()
+	}
+
+	var macros = cfg.macros
+	if macros == nil {
+		macros = append(macros, macroField{key: "time", macro: "asctime"})
+		macros = append(macros, macroField{key: "level", macro: "levelname"})
+	}
+
+	var registry = cfg.Registry
+	if registry == nil {
+		registry = defaultRegistry
+	}
+
+	var handler = registry.GetHandler("")
+	handler.SetEncoding(enc)
+	if cfg.TimeLayout != "" {
+		handler.SetTimeLayout(cfg.TimeLayout)
+	}
+	if cfg.Timezone != nil {
+		handler.SetTimezone(cfg.Timezone)
+	}
+	for i := range macros {
+		if err := handler.AddMacro(macros[i].key, macros[i].macro); err != nil {
+			return nil, err
+		}
+	}
+
 	var filemode = cfg.Filemode
 	if filemode == 0 {
 		filemode = os.O_APPEND | os.O_CREATE | os.O_WRONLY
@@ -218,32 +241,14 @@ func (cfg SimpleConfig) Apply() (*Logger, error) {
 		}
 	}
 
-	var emitter = NewStreamEmitter(writer)
-
-	var enc = cfg.Encoding
-	if enc == nil {
-		enc = encoding.NewTextEncoding()
-	}
-
-	var macros = cfg.macros
-	if macros == nil {
-		macros = append(macros, macroField{key: "time", macro: "asctime"})
-		macros = append(macros, macroField{key: "level", macro: "levelname"})
-	}
-
-	var handler = GetHandler("")
-	handler.AddEmitter(emitter)
-	handler.SetEncoding(enc)
-	for i := range macros {
-		handler.AddMacro(macros[i].key, macros[i].macro)
-	}
+	handler.AddEmitter(registry.NewStreamEmitter(writer))
 
 	var level = cfg.Level
 	if level == 0 {
 		level = WARNING
 	}
 
-	var logger = GetLogger(cfg.Name)
+	var logger = registry.GetLogger(cfg.Name)
 	logger.AddHandler(handler)
 	logger.SetLevel(level)
 
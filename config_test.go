@@ -58,6 +58,14 @@ func TestSimpleConfig(t *testing.T) {
 	xycond.ExpectIn(`"level":"ERROR","messsage":"foo"`, writer.Captured).Test(t)
 }
 
+func TestSimpleConfigInvalidMacro(t *testing.T) {
+	var writer = &test.MockWriter{}
+	var cfg = &xylog.SimpleConfig{Writer: writer}
+	var _, err = cfg.AddMacro("foo", "unknown").Apply()
+
+	xycond.ExpectError(err, xyerror.ValueError).Test(t)
+}
+
 func TestSimpleConfigBothFilenameAndWriter(t *testing.T) {
 	var writer = &test.MockWriter{}
 	var _, err = xylog.SimpleConfig{
@@ -0,0 +1,80 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"context"
+
+	"github.com/xybor-x/xycond"
+)
+
+// ContextExtractor pulls a field out of a context.Context, e.g. a request ID,
+// a tenant, or a user. It returns false if the context has no such field.
+type ContextExtractor func(ctx context.Context) (key string, value any, ok bool)
+
+// contextExtractors contains all registered ContextExtractors. It is copied on
+// write, so a list got under globalLock can be used after releasing the lock.
+var contextExtractors []ContextExtractor
+
+// AddContextExtractor registers a ContextExtractor. Fields extracted by it are
+// added to every LogRecord logged with a context, such as by Logger.InfoContext
+// or EventLogger.Ctx.
+func AddContextExtractor(x ContextExtractor) {
+	xycond.AssertNotNil(x)
+	globalLock.WLockFunc(func() {
+		contextExtractors = appendCopy(contextExtractors, x)
+	})
+}
+
+// extractContext appends fields extracted from the context by all registered
+// ContextExtractors to the field list. The extractors are called without
+// holding globalLock, so they can use other functions of this package.
+func extractContext(ctx context.Context, fields []field) []field {
+	globalLock.RLock()
+	var extractors = contextExtractors
+	globalLock.RUnlock()
+
+	for i := range extractors {
+		if key, value, ok := extractors[i](ctx); ok {
+			fields = append(fields, makeField(key, value))
+		}
+	}
+	return fields
+}
+
+// loggerKey is the key of Logger in context.Context.
+type loggerKey struct{}
+
+// NewContext returns a copy of the context carrying the Logger. It is usually
+// used by middlewares to attach a request-scoped Logger derived by With.
+func NewContext(ctx context.Context, lg *Logger) context.Context {
+	xycond.AssertNotNil(lg)
+	return context.WithValue(ctx, loggerKey{}, lg)
+}
+
+// FromContext returns the Logger carried by the context. If there is no
+// Logger, it returns the root Logger.
+func FromContext(ctx context.Context) *Logger {
+	if lg, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return lg
+	}
+	return GetLogger("")
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+type requestIDKey struct{}
+
+func init() {
+	xylog.AddContextExtractor(func(ctx context.Context) (string, any, bool) {
+		var id, ok = ctx.Value(requestIDKey{}).(string)
+		return "request_id", id, ok
+	})
+}
+
+func TestLoggerContextMethods(t *testing.T) {
+	var ctx = context.WithValue(context.Background(), requestIDKey{}, "abc")
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var tests = []func(context.Context, string){
+			logger.TraceContext,
+			logger.DebugContext,
+			logger.InfoContext,
+			logger.NoticeContext,
+			logger.WarnContext,
+			logger.WarningContext,
+			logger.ErrorContext,
+			logger.CriticalContext,
+		}
+
+		logger.SetLevel(xylog.TRACE)
+		for i := range tests {
+			w.Reset()
+			tests[i](ctx, "foo")
+			xycond.ExpectEqual(w.Captured, "messsage=foo request_id=abc\n").
+				Test(t)
+		}
+
+		w.Reset()
+		logger.LogContext(ctx, xylog.DEBUG, "foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo request_id=abc\n").Test(t)
+
+		w.Reset()
+		logger.InfoContext(context.Background(), "foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestEventLoggerCtx(t *testing.T) {
+	var ctx = context.WithValue(context.Background(), requestIDKey{}, "abc")
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.Event("foo").Ctx(ctx).Field("bar", 1).Error()
+		xycond.ExpectEqual(w.Captured, "event=foo bar=1 request_id=abc\n").
+			Test(t)
+
+		w.Reset()
+		logger.Event("foo").Error()
+		xycond.ExpectEqual(w.Captured, "event=foo\n").Test(t)
+	})
+}
+
+func TestLogRecordContext(t *testing.T) {
+	var ctx = context.WithValue(context.Background(), requestIDKey{}, "abc")
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var filter = &contextFilter{}
+		logger.AddFilter(filter)
+		logger.ErrorContext(ctx, "foo")
+		xycond.ExpectEqual(filter.ctx, ctx).Test(t)
+	})
+}
+
+func TestAddContextExtractorNil(t *testing.T) {
+	xycond.ExpectPanic(xyerror.AssertionError, func() {
+		xylog.AddContextExtractor(nil)
+	}).Test(t)
+}
+
+type contextFilter struct {
+	ctx context.Context
+}
+
+func (f *contextFilter) Filter(r xylog.LogRecord) bool {
+	f.ctx = r.Context
+	return true
+}
+
+func TestLoggerContextFindCaller(t *testing.T) {
+	xylog.SetFindCaller(true)
+	defer xylog.SetFindCaller(false)
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.Handlers()[0].AddMacro("funcname", "funcname")
+
+		logger.ErrorContext(context.Background(), "foo")
+		xycond.ExpectIn("funcname=TestLoggerContextFindCaller.func1",
+			w.Captured).Test(t)
+
+		w.Reset()
+		logger.Event("foo").Ctx(context.Background()).Error()
+		xycond.ExpectIn("funcname=TestLoggerContextFindCaller.func1",
+			w.Captured).Test(t)
+	})
+}
+
+func TestNewContext(t *testing.T) {
+	var logger = xylog.GetLogger(t.Name()).With("request_id", "abc")
+	var ctx = xylog.NewContext(context.Background(), logger)
+	xycond.ExpectEqual(xylog.FromContext(ctx), logger).Test(t)
+}
+
+func TestFromContextRoot(t *testing.T) {
+	xycond.ExpectEqual(xylog.FromContext(context.Background()),
+		xylog.GetLogger("")).Test(t)
+}
+
+func TestNewContextNil(t *testing.T) {
+	xycond.ExpectPanic(xyerror.AssertionError, func() {
+		xylog.NewContext(context.Background(), nil)
+	}).Test(t)
+}
+
+type reentrantKey struct{}
+
+func TestContextExtractorReentrant(t *testing.T) {
+	xylog.AddContextExtractor(func(ctx context.Context) (string, any, bool) {
+		if ctx.Value(reentrantKey{}) == nil {
+			return "", nil, false
+		}
+		// These functions need the write lock of the module.
+		xylog.AddLevel(xylog.INFO, "INFO")
+		xylog.SetSeverity(xylog.INFO, xylog.GetSeverity(xylog.INFO))
+		return "reentrant", true, true
+	})
+
+	var ctx = context.WithValue(context.Background(), reentrantKey{}, true)
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var done = make(chan struct{})
+		go func() {
+			defer close(done)
+			logger.ErrorContext(ctx, "foo")
+		}()
+
+		select {
+		case <-done:
+			xycond.ExpectIn("reentrant=true", w.Captured).Test(t)
+		case <-time.After(time.Second):
+			t.Fatal("the context extractor is deadlocked")
+		}
+	})
+}
@@ -24,15 +24,18 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"runtime/debug"
 
 	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
 	"github.com/xybor-x/xylock"
 )
 
 // Emitter instances dispatch logging events to specific destinations.
 type Emitter interface {
-	// Emit will be called after a record was decided to log.
+	// Emit will be called after a record was decided to log. The byte slice
+	// is reused after Emit returns, so it must not be retained.
 	Emit([]byte)
 
 	// Flush writes unflushed buffered data to destination, then closes the
@@ -42,33 +45,42 @@ type Emitter interface {
 
 // StreamEmitter writes logging message to a stream.
 type StreamEmitter struct {
-	w    io.Writer
-	lock *xylock.Lock
+	w      io.Writer
+	closer io.Closer
+	closed bool
+	lock   *xylock.Lock
 }
 
 // NewBufferEmitter creates a StreamEmitter which uses a Buffered Writer.
 func NewBufferEmitter(w io.Writer, bufsize int) *StreamEmitter {
+	return defaultRegistry.NewBufferEmitter(w, bufsize)
+}
+
+// NewStreamEmitter creates a StreamEmitter which writes logging message to a
+// stream.
+func NewStreamEmitter(w io.Writer) *StreamEmitter {
+	return NewBufferEmitter(w, 0)
+}
+
+// newBufferEmitter creates a StreamEmitter which uses a Buffered Writer. The
+// Emitter is not managed by any Registry.
+func newBufferEmitter(w io.Writer, bufsize int) *StreamEmitter {
 	xycond.AssertNotNil(w)
 
+	var closer, _ = w.(io.Closer)
+	if w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+		closer = nil
+	}
+
 	if bufsize != 0 {
 		w = bufio.NewWriterSize(w, bufsize)
 	}
 
-	var e = &StreamEmitter{
-		lock: &xylock.Lock{},
-		w:    w,
+	return &StreamEmitter{
+		lock:   &xylock.Lock{},
+		w:      w,
+		closer: closer,
 	}
-
-	globalLock.WLockFunc(func() {
-		emitterManager = append(emitterManager, e)
-	})
-	return e
-}
-
-// NewStreamEmitter creates a StreamEmitter which writes logging message to a
-// stream.
-func NewStreamEmitter(w io.Writer) *StreamEmitter {
-	return NewBufferEmitter(w, 0)
 }
 
 // Emit will be called after a record was decided to log.
@@ -76,7 +88,12 @@ func (e *StreamEmitter) Emit(msg []byte) {
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
-	var _, err = e.w.Write(msg)
+	var err error
+	if e.closed {
+		err = xyerror.IOError.New("emit to a closed emitter")
+	} else {
+		_, err = e.w.Write(msg)
+	}
 	if err == nil {
 		_, err = e.w.Write([]byte("\n"))
 	}
@@ -92,6 +109,32 @@ func (e *StreamEmitter) Flush() {
 	if w, ok := e.w.(*bufio.Writer); ok {
 		e.lock.Lock()
 		defer e.lock.Unlock()
-		w.Flush()
+		if !e.closed {
+			w.Flush()
+		}
+	}
+}
+
+// Close writes unflushed buffered data to destination, then closes the stream
+// if it is an io.Closer other than os.Stdout and os.Stderr. Messages emitted
+// after closing are reported as errors.
+func (e *StreamEmitter) Close() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var err error
+	if w, ok := e.w.(*bufio.Writer); ok {
+		err = w.Flush()
+	}
+	if e.closer != nil {
+		if cerr := e.closer.Close(); err == nil {
+			err = cerr
+		}
 	}
+	return err
 }
@@ -21,6 +21,7 @@
 package xylog_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/xybor-x/xycond"
@@ -51,3 +52,33 @@ func TestStreamEmitterEmitError(t *testing.T) {
 		xycond.ExpectEmpty(w.Captured).Test(t)
 	})
 }
+
+func TestStreamEmitterClose(t *testing.T) {
+	var w = &test.MockWriter{}
+	var e = xylog.NewBufferEmitter(w, 1024)
+	e.Emit([]byte("foo"))
+	xycond.ExpectEmpty(w.Captured).Test(t)
+
+	xycond.ExpectNil(e.Close()).Test(t)
+	xycond.ExpectEqual(w.Captured, "foo\n").Test(t)
+	xycond.ExpectTrue(w.Closed).Test(t)
+
+	e.Emit([]byte("bar"))
+	e.Flush()
+	xycond.ExpectEqual(w.Captured, "foo\n").Test(t)
+	xycond.ExpectNil(e.Close()).Test(t)
+}
+
+func TestStreamEmitterCloseError(t *testing.T) {
+	var w = &test.MockWriter{Error: true}
+	var e = xylog.NewStreamEmitter(w)
+	xycond.ExpectError(e.Close(), xyerror.BaseException).Test(t)
+}
+
+func TestStreamEmitterCloseStdout(t *testing.T) {
+	var e = xylog.NewStreamEmitter(os.Stdout)
+	xycond.ExpectNil(e.Close()).Test(t)
+
+	var _, err = os.Stdout.Write(nil)
+	xycond.ExpectNil(err).Test(t)
+}
@@ -44,6 +44,11 @@ func (b *Buffer) AppendString(s string) {
 	b.buf = append(b.buf, s...)
 }
 
+// AppendBytes writes a byte slice to the Buffer.
+func (b *Buffer) AppendBytes(a []byte) {
+	b.buf = append(b.buf, a...)
+}
+
 // AppendByte writes a byte to the Buffer.
 func (b *Buffer) AppendByte(a byte) {
 	b.buf = append(b.buf, a)
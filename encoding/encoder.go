@@ -48,6 +48,14 @@ type Encoding interface {
 	// clone creates a new TextEncoder with the copy of underlying buffer.
 	clone() Encoding
 
+	// fragment creates an empty Encoding with the same format, which encodes
+	// fields without any enclosing, so its result can be added to other
+	// Encodings by addFragment.
+	fragment() Encoding
+
+	// addFragment adds fields encoded by a fragment to the Encoder.
+	addFragment(b []byte)
+
 	// free clears the buffer.
 	free()
 }
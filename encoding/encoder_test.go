@@ -22,6 +22,7 @@ package encoding_test
 
 import (
 	"errors"
+	"sync"
 	"testing"
 
 	"github.com/xybor-x/xycond"
@@ -113,3 +114,104 @@ func TestJSONEncoderFree(t *testing.T) {
 
 	xycond.ExpectEmpty(encoder.Encode()).Test(t)
 }
+
+// pair is a key-value pair added by encoding.AddFields.
+type pair struct {
+	key   string
+	value any
+}
+
+func pairKV(p pair) (string, any) {
+	return p.key, p.value
+}
+
+func TestTextEncoderAddFields(t *testing.T) {
+	var fields = &encoding.Fields{}
+	var pairs = []pair{{"foo", "bar baz"}, {"n", 1}}
+
+	for i := 0; i < 3; i++ {
+		var encoder = encoding.NewEncoder(encoding.NewTextEncoding())
+		encoder.Add("a", 1)
+		encoding.AddFields(encoder, fields, pairs, pairKV)
+		encoder.Add("b", 2)
+		xycond.ExpectEqual(string(encoder.Encode()),
+			`a=1 foo="bar baz" n=1 b=2`).Test(t)
+		encoder.Free()
+	}
+
+	var encoder = encoding.NewEncoder(encoding.NewTextEncoding())
+	encoding.AddFields(encoder, fields, pairs, pairKV)
+	encoding.AddFields(encoder, &encoding.Fields{}, nil, pairKV)
+	xycond.ExpectEqual(string(encoder.Encode()), `foo="bar baz" n=1`).Test(t)
+}
+
+func TestJSONEncoderAddFields(t *testing.T) {
+	var fields = &encoding.Fields{}
+	var pairs = []pair{{"foo", "bar"}, {"n", 1}}
+
+	for i := 0; i < 3; i++ {
+		var encoder = encoding.NewEncoder(encoding.NewJSONEncoding())
+		encoding.AddFields(encoder, fields, pairs, pairKV)
+		encoder.Add("b", 2)
+		xycond.ExpectEqual(string(encoder.Encode()),
+			`{"foo":"bar","n":1,"b":2}`).Test(t)
+		encoder.Free()
+	}
+}
+
+func TestEncoderAddFieldsCached(t *testing.T) {
+	var fields = &encoding.Fields{}
+	var pairs = []pair{{"foo", "bar"}}
+	var encode = func() string {
+		var encoder = encoding.NewEncoder(encoding.NewTextEncoding())
+		encoding.AddFields(encoder, fields, pairs, pairKV)
+		return string(encoder.Encode())
+	}
+
+	// The pairs are encoded directly the first time, then they are cached
+	// the second time and reused after that.
+	xycond.ExpectEqual(encode(), "foo=bar").Test(t)
+	pairs[0].value = "baz"
+	xycond.ExpectEqual(encode(), "foo=baz").Test(t)
+	pairs[0].value = "qux"
+	xycond.ExpectEqual(encode(), "foo=baz").Test(t)
+}
+
+func TestEncoderAddFieldsMixedEncodings(t *testing.T) {
+	var fields = &encoding.Fields{}
+	var pairs = []pair{{"foo", "bar"}}
+
+	for i := 0; i < 2; i++ {
+		var text = encoding.NewEncoder(encoding.NewTextEncoding())
+		encoding.AddFields(text, fields, pairs, pairKV)
+		var json = encoding.NewEncoder(encoding.NewJSONEncoding())
+		encoding.AddFields(json, fields, pairs, pairKV)
+
+		xycond.ExpectEqual(string(text.Encode()), `foo=bar`).Test(t)
+		xycond.ExpectEqual(string(json.Encode()), `{"foo":"bar"}`).Test(t)
+	}
+}
+
+func TestEncoderAddFieldsConcurrently(t *testing.T) {
+	var fields = &encoding.Fields{}
+	var pairs = []pair{{"foo", "bar"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var e = encoding.NewTextEncoding()
+			var expected = `foo=bar`
+			if i%2 == 0 {
+				e = encoding.NewJSONEncoding()
+				expected = `{"foo":"bar"}`
+			}
+
+			var encoder = encoding.NewEncoder(e)
+			encoding.AddFields(encoder, fields, pairs, pairKV)
+			xycond.ExpectEqual(string(encoder.Encode()), expected).Test(t)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package encoding
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Fields caches key-value pairs encoded by each type of Encoding, see
+// AddFields. The pairs themselves are not kept by Fields. The zero value is
+// ready to use.
+type Fields struct {
+	// encoded is the latest *encodedFields, it is loaded without locking.
+	encoded atomic.Value
+
+	// used is set to 1 when the pairs are added for the first time.
+	used uint32
+}
+
+// encodedFields is an immutable list of key-value pairs encoded by different
+// Encodings.
+type encodedFields struct {
+	format reflect.Type
+	b      []byte
+	next   *encodedFields
+}
+
+// AddFields adds key-value pairs got from the items by kv to the Encoder. The
+// first time, the pairs are encoded directly into the Encoder. After that, they
+// are encoded once by each type of Encoding, cached in the Fields, and reused.
+// So the items and their values must be the same in all calls with the same
+// Fields.
+func AddFields[T any](
+	encoder *Encoder, f *Fields, items []T, kv func(T) (string, any),
+) {
+	if len(items) == 0 {
+		return
+	}
+
+	var format = reflect.TypeOf(encoder.encoding)
+	var old = f.encoded.Load()
+	var head, _ = old.(*encodedFields)
+	for ef := head; ef != nil; ef = ef.next {
+		if ef.format == format {
+			encoder.encoding.addFragment(ef.b)
+			return
+		}
+	}
+
+	// Most derived Loggers log only one record, it is not worth caching.
+	if atomic.CompareAndSwapUint32(&f.used, 0, 1) {
+		for i := range items {
+			encoder.Add(kv(items[i]))
+		}
+		return
+	}
+
+	var fragment = Encoder{encoding: encoder.encoding.fragment()}
+	for i := range items {
+		fragment.Add(kv(items[i]))
+	}
+	var b = append([]byte(nil), fragment.Encode()...)
+	fragment.Free()
+	encoder.encoding.addFragment(b)
+
+	// If another goroutine stored first, the fields will be encoded again next
+	// time, which is still correct.
+	f.encoded.CompareAndSwap(old, &encodedFields{format: format, b: b, next: head})
+}
@@ -30,6 +30,9 @@ func NewJSONEncoding() Encoding {
 // jsonEncoding creates a buffer with json format.
 type jsonEncoding struct {
 	buf *Buffer
+
+	// bare is true if the fields are not enclosed by braces, see fragment.
+	bare bool
 }
 
 // addString adds a field of string to encoder.
@@ -84,7 +87,7 @@ func (e *jsonEncoding) addFloat64(k string, v float64) {
 
 // encode finishes the encoding process and returns the final byte slice.
 func (e *jsonEncoding) encode() []byte {
-	if e.buf.Len() > 0 {
+	if e.buf.Len() > 0 && !e.bare {
 		e.closeNamespace()
 	}
 	return e.buf.Bytes()
@@ -95,6 +98,17 @@ func (e *jsonEncoding) clone() Encoding {
 	return &jsonEncoding{buf: e.buf.Clone()}
 }
 
+// fragment creates an empty jsonEncoding without braces.
+func (e *jsonEncoding) fragment() Encoding {
+	return &jsonEncoding{buf: NewBuffer(), bare: true}
+}
+
+// addFragment adds fields encoded by a fragment to encoder.
+func (e *jsonEncoding) addFragment(b []byte) {
+	e.addSeperator()
+	e.buf.AppendBytes(b)
+}
+
 // free clears the buffer.
 func (e *jsonEncoding) free() {
 	e.buf.Free()
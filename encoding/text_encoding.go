@@ -99,6 +99,17 @@ func (e *textEncoding) clone() Encoding {
 	return &textEncoding{buf: e.buf.Clone()}
 }
 
+// fragment creates an empty textEncoding.
+func (e *textEncoding) fragment() Encoding {
+	return NewTextEncoding()
+}
+
+// addFragment adds fields encoded by a fragment to encoder.
+func (e *textEncoding) addFragment(b []byte) {
+	e.addSeperator()
+	e.buf.AppendBytes(b)
+}
+
 // free clears the buffer.
 func (e *textEncoding) free() {
 	e.buf.Free()
@@ -21,6 +21,7 @@
 package xylog
 
 import (
+	"context"
 	"os"
 	"sync"
 )
@@ -36,6 +37,7 @@ var eventLoggerPool = sync.Pool{
 type EventLogger struct {
 	fields []field
 	lg     *Logger
+	ctx    context.Context
 }
 
 // Field adds a key-value pair to logging message.
@@ -44,11 +46,26 @@ func (e *EventLogger) Field(key string, value any) *EventLogger {
 	return e
 }
 
+// Ctx sets the context of logging message. Fields extracted from the context
+// by ContextExtractors are added to the message.
+func (e *EventLogger) Ctx(ctx context.Context) *EventLogger {
+	e.ctx = ctx
+	return e
+}
+
+// Trace calls Log with TRACE level.
+func (e *EventLogger) Trace() {
+	defer e.free()
+	if e.lg.isEnabledFor(TRACE) {
+		e.lg.log(e.ctx, TRACE, e.fields...)
+	}
+}
+
 // Debug calls Log with DEBUG level.
 func (e *EventLogger) Debug() {
 	defer e.free()
 	if e.lg.isEnabledFor(DEBUG) {
-		e.lg.log(DEBUG, e.fields...)
+		e.lg.log(e.ctx, DEBUG, e.fields...)
 	}
 }
 
@@ -56,7 +73,15 @@ func (e *EventLogger) Debug() {
 func (e *EventLogger) Info() {
 	defer e.free()
 	if e.lg.isEnabledFor(INFO) {
-		e.lg.log(INFO, e.fields...)
+		e.lg.log(e.ctx, INFO, e.fields...)
+	}
+}
+
+// Notice calls Log with NOTICE level.
+func (e *EventLogger) Notice() {
+	defer e.free()
+	if e.lg.isEnabledFor(NOTICE) {
+		e.lg.log(e.ctx, NOTICE, e.fields...)
 	}
 }
 
@@ -64,7 +89,7 @@ func (e *EventLogger) Info() {
 func (e *EventLogger) Warn() {
 	defer e.free()
 	if e.lg.isEnabledFor(WARN) {
-		e.lg.log(WARN, e.fields...)
+		e.lg.log(e.ctx, WARN, e.fields...)
 	}
 }
 
@@ -72,7 +97,7 @@ func (e *EventLogger) Warn() {
 func (e *EventLogger) Warning() {
 	defer e.free()
 	if e.lg.isEnabledFor(WARNING) {
-		e.lg.log(WARNING, e.fields...)
+		e.lg.log(e.ctx, WARNING, e.fields...)
 	}
 }
 
@@ -80,7 +105,7 @@ func (e *EventLogger) Warning() {
 func (e *EventLogger) Error() {
 	defer e.free()
 	if e.lg.isEnabledFor(ERROR) {
-		e.lg.log(ERROR, e.fields...)
+		e.lg.log(e.ctx, ERROR, e.fields...)
 	}
 }
 
@@ -88,7 +113,7 @@ func (e *EventLogger) Error() {
 func (e *EventLogger) Critical() {
 	defer e.free()
 	if e.lg.isEnabledFor(CRITICAL) {
-		e.lg.log(CRITICAL, e.fields...)
+		e.lg.log(e.ctx, CRITICAL, e.fields...)
 	}
 }
 
@@ -109,12 +134,33 @@ func (e *EventLogger) Log(level int) {
 	defer e.free()
 	level = CheckLevel(level)
 	if e.lg.isEnabledFor(level) {
-		e.lg.log(level, e.fields...)
+		e.lg.log(e.ctx, level, e.fields...)
+	}
+}
+
+// badKey is the key of values which are not in a valid key-value pair.
+const badKey = "!BADKEY"
+
+// appendPairs converts loosely typed key-value pairs to fields, then appends
+// them to the field list. A non-string key or a key without value is added
+// under the badKey instead of being dropped.
+func appendPairs(fields []field, keysAndValues []any) []field {
+	for i := 0; i < len(keysAndValues); i++ {
+		var key, ok = keysAndValues[i].(string)
+		if !ok || i == len(keysAndValues)-1 {
+			fields = append(fields, makeField(badKey, keysAndValues[i]))
+			continue
+		}
+
+		fields = append(fields, makeField(key, keysAndValues[i+1]))
+		i++
 	}
+	return fields
 }
 
 // free clears fields in EventLogger and puts it to pool.
 func (e *EventLogger) free() {
 	e.fields = e.fields[:0]
+	e.ctx = nil
 	eventLoggerPool.Put(e)
 }
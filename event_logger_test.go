@@ -31,9 +31,14 @@ import (
 
 func TestEventLogger(t *testing.T) {
 	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
-		logger.SetLevel(xylog.DEBUG)
+		logger.SetLevel(xylog.TRACE)
 		var msg = test.GetRandomMessage()
 
+		w.Reset()
+		logger.Event(msg).Trace()
+		xycond.ExpectIn(fmt.Sprintf("event=\"%s\"", msg), w.Captured).
+			Test(t)
+
 		w.Reset()
 		logger.Event(msg).Debug()
 		xycond.ExpectIn(fmt.Sprintf("event=\"%s\"", msg), w.Captured).
@@ -44,6 +49,11 @@ func TestEventLogger(t *testing.T) {
 		xycond.ExpectIn(fmt.Sprintf("event=\"%s\"", msg), w.Captured).
 			Test(t)
 
+		w.Reset()
+		logger.Event(msg).Notice()
+		xycond.ExpectIn(fmt.Sprintf("event=\"%s\"", msg), w.Captured).
+			Test(t)
+
 		w.Reset()
 		logger.Event(msg).Warn()
 		xycond.ExpectIn(fmt.Sprintf("event=\"%s\"", msg), w.Captured).
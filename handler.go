@@ -21,7 +21,9 @@
 package xylog
 
 import (
-	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/xybor-x/xycond"
 	"github.com/xybor-x/xylock"
@@ -32,15 +34,28 @@ import (
 //
 // Any Handler with a not-empty name will be associated with its name.
 type Handler struct {
-	f *filterer
+	name string
 
-	name     string
-	emitters []Emitter
+	// lock serializes changes of the Handler. Handle never takes it.
+	lock *xylock.RWLock
+
+	// config is the current *handlerConfig.
+	config atomic.Value
+}
+
+// handlerConfig is the configuration of a Handler. It is never modified after
+// being published, a change of the Handler publishes a modified copy instead.
+// So Handle always reads a consistent configuration without locking.
+type handlerConfig struct {
 	level    int
-	lock     *xylock.RWLock
-	encoder  *encoding.Encoder
-	macros   []macroField
-	fields   []field
+	filters  []Filter
+	emitters []Emitter
+
+	// encoder contains the encoded fields. It is cloned to format a record.
+	encoder    *encoding.Encoder
+	macros     []macro
+	fields     []field
+	timeFormat timeFormat
 }
 
 // GetHandler gets a handler with the specified name, creating it if it doesn't
@@ -48,22 +63,49 @@ type Handler struct {
 //
 // Leave the name as empty if you want to create an anonymous Handler.
 func GetHandler(name string) *Handler {
-	var h, ok = handlerManager[name]
-	if ok {
-		return h
-	}
+	return defaultRegistry.GetHandler(name)
+}
 
-	h = &Handler{
-		f:       &filterer{},
-		name:    name,
-		level:   NOTSET,
-		lock:    &xylock.RWLock{},
-		encoder: encoding.NewEncoder(encoding.NewTextEncoding()),
-	}
-	if name != "" {
-		mapHandler(name, h)
-	}
+// RemoveHandler removes the Handler with the specified name from all Loggers,
+// then forgets it. A later GetHandler call with the same name creates a new
+// Handler. The removed Handler is not closed.
+func RemoveHandler(name string) {
+	defaultRegistry.RemoveHandler(name)
+}
+
+// ReplaceHandler associates the name with the given anonymous Handler instead
+// of the current one, and replaces the current one with the given one in all
+// Loggers. It returns the replaced Handler, which is not closed, or nil if no
+// Handler was associated with the name.
+//
+// It allows reloading the configuration of a Handler without losing any
+// logging message:
+//
+//	var handler = xylog.GetHandler("")
+//	// Configure the new Handler.
+//	var old = xylog.ReplaceHandler("console", handler)
+//	old.Close()
+func ReplaceHandler(name string, h *Handler) *Handler {
+	return defaultRegistry.ReplaceHandler(name, h)
+}
+
+// Handlers returns all named Handlers, sorted by name.
+func Handlers() []*Handler {
+	return defaultRegistry.Handlers()
+}
 
+// newHandler creates a Handler with the given name and default time layout.
+// The Handler is not managed by any Registry.
+func newHandler(name, layout string) *Handler {
+	var h = &Handler{
+		name: name,
+		lock: &xylock.RWLock{},
+	}
+	h.config.Store(&handlerConfig{
+		level:      NOTSET,
+		encoder:    encoding.NewEncoder(encoding.NewTextEncoding()),
+		timeFormat: timeFormat{layout: layout},
+	})
 	return h
 }
 
@@ -74,119 +116,238 @@ func (h *Handler) Name() string {
 
 // Level returns the current logging level.
 func (h *Handler) Level() int {
-	return h.lock.RLockFunc(func() any { return h.level }).(int)
+	return h.loadConfig().level
 }
 
 // SetLevel sets the new logging level. It is NOTSET by default.
 func (h *Handler) SetLevel(level int) {
-	h.lock.WLockFunc(func() { h.level = level })
+	h.update(func(c *handlerConfig) { c.level = level })
 }
 
 // SetEncoding sets a new Encoding.
 func (h *Handler) SetEncoding(e encoding.Encoding) {
-	h.lock.Lock()
-	defer h.lock.Unlock()
-	h.encoder = encoding.NewEncoder(e)
-	for i := range h.fields {
-		h.encoder.Add(h.fields[i].key, h.fields[i].value)
-	}
+	h.update(func(c *handlerConfig) {
+		c.encoder = encoding.NewEncoder(e)
+		for i := range c.fields {
+			c.encoder.Add(c.fields[i].key, c.fields[i].value)
+		}
+	})
 }
 
-// Filters returns all current Filters.
+// Filters returns a copy of all current Filters.
 func (h *Handler) Filters() []Filter {
-	return h.lock.RLockFunc(func() any { return h.f.Filters() }).([]Filter)
+	return append([]Filter(nil), h.loadConfig().filters...)
 }
 
 // AddFilter adds a specified Filter.
 func (h *Handler) AddFilter(f Filter) {
-	h.lock.WLockFunc(func() { h.f.AddFilter(f) })
+	h.update(func(c *handlerConfig) { c.filters = appendCopy(c.filters, f) })
 }
 
 // RemoveFilter remove an existed Filter.
 func (h *Handler) RemoveFilter(f Filter) {
-	h.lock.WLockFunc(func() { h.f.RemoveFilter(f) })
+	h.update(func(c *handlerConfig) {
+		var filters []Filter
+		for i := range c.filters {
+			if c.filters[i] != f {
+				filters = append(filters, c.filters[i])
+			}
+		}
+		c.filters = filters
+	})
 }
 
-// Emitters returns all current Emitters.
+// Emitters returns a copy of all current Emitters.
 func (h *Handler) Emitters() []Emitter {
-	return h.lock.RLockFunc(func() any { return h.emitters }).([]Emitter)
+	return append([]Emitter(nil), h.loadConfig().emitters...)
 }
 
 // AddEmitter adds a specified Emitter.
 func (h *Handler) AddEmitter(e Emitter) {
-	h.lock.WLockFunc(func() { h.emitters = append(h.emitters, e) })
+	h.update(func(c *handlerConfig) { c.emitters = appendCopy(c.emitters, e) })
 }
 
 // RemoveEmitter remove an existed Emitter.
 func (h *Handler) RemoveEmitter(e Emitter) {
-	h.lock.Lock()
-	defer h.lock.Unlock()
+	h.update(func(c *handlerConfig) {
+		var emitters []Emitter
+		for i := range c.emitters {
+			if c.emitters[i] != e {
+				emitters = append(emitters, c.emitters[i])
+			}
+		}
+		c.emitters = emitters
+	})
+}
 
-	for i := range h.emitters {
-		if h.emitters[i] == e {
-			h.emitters = append(h.emitters[:i], h.emitters[i+1:]...)
+// Close closes all Emitters of the Handler which implement io.Closer, and
+// flushes the others. It returns the first error occurred.
+func (h *Handler) Close() error {
+	var err error
+	for _, e := range h.Emitters() {
+		if c, ok := e.(io.Closer); ok {
+			if cerr := c.Close(); err == nil {
+				err = cerr
+			}
+		} else {
+			e.Flush()
 		}
 	}
+	return err
+}
+
+// SetTimeLayout sets the time layout to format asctime macro. It can be a
+// layout of time package or one of Unix layouts. By default, it is the layout
+// set by the global SetTimeLayout when the Handler is created.
+func (h *Handler) SetTimeLayout(layout string) {
+	h.update(func(c *handlerConfig) {
+		c.timeFormat.layout = layout
+		c.recompileMacros()
+	})
+}
+
+// SetTimezone sets the timezone to format asctime macro, e.g. time.UTC,
+// time.Local, or a location loaded by time.LoadLocation. By default, the time
+// is formatted in the timezone of the record.
+func (h *Handler) SetTimezone(loc *time.Location) {
+	xycond.AssertNotNil(loc)
+	h.update(func(c *handlerConfig) {
+		c.timeFormat.location = loc
+		c.recompileMacros()
+	})
 }
 
-// AddMacro adds the macro value to the logging message under a name.
-func (h *Handler) AddMacro(name, macro string) {
-	h.lock.WLockFunc(func() {
-		h.macros = append(h.macros, macroField{key: name, macro: macro})
+// AddMacro adds the macro value to the logging message under a name. The macro
+// is resolved immediately, an error is returned if it is unknown.
+func (h *Handler) AddMacro(name, macro string) error {
+	var err error
+	h.update(func(c *handlerConfig) {
+		var get MacroFunc
+		get, err = compileMacro(macro, c.timeFormat)
+		if err == nil {
+			c.macros = appendCopy(c.macros, makeMacro(name, macro, get))
+		}
 	})
+	return err
 }
 
 // AddField adds a fixed field to the logging message.
 func (h *Handler) AddField(name string, value any) {
-	h.lock.WLockFunc(func() {
-		h.fields = append(h.fields, makeField(name, value))
-		h.encoder.Add(name, value)
+	h.update(func(c *handlerConfig) {
+		c.fields = appendCopy(c.fields, makeField(name, value))
+		c.encoder = c.encoder.Clone()
+		c.encoder.Add(name, value)
 	})
 }
 
 // Handle checks if a record should be logged or not, then calls Emitters if it
-// is.
+// is. Lazy fields of the record are only evaluated if it will be logged.
 func (h *Handler) Handle(record LogRecord) {
-	if h.filter(record) && record.LevelNo >= h.Level() {
-		var msg, err = h.format(record)
-		if err != nil {
-			msg = []byte(fmt.Sprintf(
-				"An error occurred while formatting the message (%s)", err))
-		}
-		var emitters = h.Emitters()
-		for i := range emitters {
-			emitters[i].Emit(msg)
-		}
+	var c = h.loadConfig()
+	if record.LevelNo < c.level || !c.filter(record) {
+		return
 	}
+
+	resolveLazyFields(record.Fields)
+	var encoder = c.format(record)
+	var msg = encoder.Encode()
+	for i := range c.emitters {
+		c.emitters[i].Emit(msg)
+	}
+	// The message is a part of the encoder, so it must be freed after all
+	// Emitters finish.
+	encoder.Free()
+}
+
+// loadConfig returns the current configuration.
+func (h *Handler) loadConfig() *handlerConfig {
+	return h.config.Load().(*handlerConfig)
+}
+
+// update publishes a copy of the current configuration modified by f. The f
+// must not modify slices of the configuration in place.
+func (h *Handler) update(f func(c *handlerConfig)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var c = *h.loadConfig()
+	f(&c)
+	h.config.Store(&c)
 }
 
-// format creates the logging message based on the encoding.
-func (h Handler) format(record LogRecord) ([]byte, error) {
-	var encoder = h.encoder.Clone()
-	defer encoder.Free()
+// format creates an Encoder containing the logging message based on the
+// encoding. The Encoder must be freed after using the message.
+func (c *handlerConfig) format(record LogRecord) *encoding.Encoder {
+	var encoder = c.encoder.Clone()
+
+	for i := range c.macros {
+		encoder.Add(c.macros[i].key, c.macros[i].get(record))
+	}
+
+	// Fields extracted from the context are skipped if a macro already adds
+	// them, e.g. trace_id, so the message never has duplicated keys.
+	var contextAt = len(record.Fields) - record.contextLen
+	var fields, extracted = record.Fields[:contextAt], record.Fields[contextAt:]
 
-	for i := range h.macros {
-		var attr, err = record.getValue(h.macros[i].macro)
-		if err != nil {
-			return nil, err
+	// Pre-encoded fields are added as a whole instead of being encoded again.
+	if record.extra != nil {
+		for _, f := range fields[:record.extraAt] {
+			encoder.Add(f.key, f.value)
 		}
-		encoder.Add(h.macros[i].key, attr)
+		var end = record.extraAt + record.extraLen
+		encoding.AddFields(encoder, record.extra, fields[record.extraAt:end], fieldKV)
+		fields = fields[end:]
 	}
 
-	for _, f := range record.Fields {
+	for _, f := range fields {
 		encoder.Add(f.key, f.value)
 	}
 
-	return encoder.Encode(), nil
+	for _, f := range extracted {
+		if !c.hasMacro(f.key) {
+			encoder.Add(f.key, f.value)
+		}
+	}
+
+	return encoder
+}
+
+// hasMacro checks if a macro is added with the key.
+func (c *handlerConfig) hasMacro(key string) bool {
+	for i := range c.macros {
+		if c.macros[i].key == key {
+			return true
+		}
+	}
+	return false
 }
 
 // filter checks all Filters, if there is any failed one, it will returns false.
-func (h *Handler) filter(r LogRecord) bool {
-	return h.lock.RLockFunc(func() any { return h.f.filter(r) }).(bool)
+func (c *handlerConfig) filter(r LogRecord) bool {
+	for i := range c.filters {
+		if !c.filters[i].Filter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// recompileMacros compiles all macros again with the current time format into
+// a new macro list. The macros were valid when being added, so no error is
+// expected here.
+func (c *handlerConfig) recompileMacros() {
+	var macros = make([]macro, len(c.macros))
+	for i := range c.macros {
+		var get, err = compileMacro(c.macros[i].spec, c.timeFormat)
+		xycond.AssertNil(err)
+		macros[i] = makeMacro(c.macros[i].key, c.macros[i].spec, get)
+	}
+	c.macros = macros
 }
 
-// mapHandler associates a name with a handler.
-func mapHandler(name string, h *Handler) {
-	xycond.AssertNotIn(name, handlerManager)
-	handlerManager[name] = h
+// appendCopy appends values to a copy of the slice. The slice itself is never
+// modified.
+func appendCopy[T any](s []T, v ...T) []T {
+	var c = make([]T, 0, len(s)+len(v))
+	return append(append(c, s...), v...)
 }
@@ -21,10 +21,15 @@
 package xylog_test
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
 	"github.com/xybor-x/xylog"
 	"github.com/xybor-x/xylog/encoding"
 	"github.com/xybor-x/xylog/test"
@@ -91,11 +96,11 @@ func TestHandlerSetEncodingAfterAddField(t *testing.T) {
 
 func TestHandlerInvalidMacro(t *testing.T) {
 	test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
-		h.AddMacro("foo", "unknown")
-		h.Handle(xylog.LogRecord{})
+		var err = h.AddMacro("foo", "unknown")
+		xycond.ExpectError(err, xyerror.ValueError).Test(t)
 
-		xycond.ExpectIn("An error occurred while formatting the message",
-			w.Captured).Test(t)
+		h.Handle(xylog.LogRecord{})
+		xycond.ExpectEqual("\n", w.Captured).Test(t)
 	})
 }
 
@@ -106,9 +111,200 @@ func TestHandlerFullMacro(t *testing.T) {
 
 		h.Handle(test.FullRecord)
 
-		xycond.ExpectEqual("asctime=ASCTIME created=1 filename=FILENAME "+
-			"funcname=FUNCNAME levelname=LEVELNAME levelno=2 lineno=3 "+
-			"module=MODULE msecs=4 pathname=PATHNAME process=5 "+
-			"relativeCreated=6\n", w.Captured).Test(t)
+		xycond.ExpectEqual("asctime=2023-01-02T03:04:05.000000006Z "+
+			"created=1 createdNanos=1672628645000000006 filename=FILENAME "+
+			"funcname=FUNCNAME goroutine=7 levelname=LEVELNAME levelno=2 "+
+			"lineno=3 module=MODULE msecs=4 pathname=PATHNAME process=5 "+
+			"relativeCreated=6 sequence=8\n", w.Captured).Test(t)
+	})
+}
+
+func TestHandlerTimeLayout(t *testing.T) {
+	var tests = []struct {
+		layout   string
+		expected string
+	}{
+		{time.RFC3339, "2023-01-02T03:04:05Z"},
+		{time.Kitchen, "3:04AM"},
+		{xylog.UnixLayout, "1672628645"},
+		{xylog.UnixMilliLayout, "1672628645000"},
+		{xylog.UnixMicroLayout, "1672628645000000"},
+		{xylog.UnixNanoLayout, "1672628645000000006"},
+	}
+
+	for i := range tests {
+		var writer = &test.MockWriter{}
+		var handler = xylog.GetHandler("")
+		handler.AddEmitter(xylog.NewStreamEmitter(writer))
+		handler.AddMacro("time", "asctime")
+		handler.SetTimeLayout(tests[i].layout)
+
+		handler.Handle(test.FullRecord)
+		xycond.ExpectEqual(writer.Captured, "time="+tests[i].expected+"\n").
+			Test(t)
+	}
+}
+
+func TestHandlerTimezone(t *testing.T) {
+	test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+		var loc = time.FixedZone("UTC+7", 7*60*60)
+		h.SetTimeLayout(time.RFC3339)
+		h.AddMacro("local", "asctime")
+		h.AddMacro("utc", "asctime|utc")
+		h.SetTimezone(loc)
+
+		h.Handle(xylog.LogRecord{Time: test.FullRecord.Time.In(time.UTC)})
+		xycond.ExpectEqual(w.Captured, "local=2023-01-02T10:04:05+07:00 "+
+			"utc=2023-01-02T03:04:05Z\n").Test(t)
+	})
+}
+
+func TestLogRecordAsctime(t *testing.T) {
+	var record = xylog.LogRecord{Time: test.FullRecord.Time.In(time.UTC)}
+	xycond.ExpectEqual(record.Asctime(),
+		"2023-01-02T03:04:05.000000006Z").Test(t)
+}
+
+func TestHandlerClose(t *testing.T) {
+	var w1, w2 = &test.MockWriter{}, &test.MockWriter{}
+	var buf = &bytes.Buffer{}
+	var handler = xylog.GetHandler("")
+	handler.AddEmitter(xylog.NewStreamEmitter(w1))
+	handler.AddEmitter(xylog.NewStreamEmitter(w2))
+	handler.AddEmitter(closelessEmitter{xylog.NewBufferEmitter(buf, 1024)})
+
+	handler.Emitters()[2].Emit([]byte("foo"))
+	w1.Error = true
+	xycond.ExpectError(handler.Close(), xyerror.BaseException).Test(t)
+	xycond.ExpectTrue(w1.Closed).Test(t)
+	xycond.ExpectTrue(w2.Closed).Test(t)
+	xycond.ExpectEqual(buf.String(), "foo\n").Test(t)
+}
+
+func TestRemoveHandler(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var handler = xylog.GetHandler(t.Name())
+	var parent = xylog.GetLogger(t.Name())
+	var child = xylog.GetLogger(t.Name() + ".child")
+	var other = xylog.GetHandler("")
+	parent.AddHandler(handler)
+	child.AddHandler(other)
+	child.AddHandler(handler)
+
+	xylog.RemoveHandler(t.Name())
+	xycond.ExpectEmpty(parent.Handlers()).Test(t)
+	xycond.ExpectEqual(len(child.Handlers()), 1).Test(t)
+	xycond.ExpectEqual(child.Handlers()[0], other).Test(t)
+	xycond.ExpectNotEqual(xylog.GetHandler(t.Name()), handler).Test(t)
+
+	xylog.RemoveHandler(t.Name() + "-unknown")
+}
+
+func TestReplaceHandler(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var writer = &test.MockWriter{}
+		var old = xylog.GetHandler(t.Name())
+		old.AddEmitter(xylog.NewStreamEmitter(writer))
+		logger.AddHandler(old)
+
+		var handler = xylog.GetHandler("")
+		handler.AddEmitter(xylog.NewStreamEmitter(writer))
+		handler.AddMacro("level", "levelname")
+
+		xycond.ExpectEqual(xylog.ReplaceHandler(t.Name(), handler), old).Test(t)
+		xycond.ExpectEqual(handler.Name(), t.Name()).Test(t)
+		xycond.ExpectEqual(xylog.GetHandler(t.Name()), handler).Test(t)
+		xycond.ExpectEqual(logger.Handlers()[1], handler).Test(t)
+
+		logger.Error("foo")
+		xycond.ExpectEqual(writer.Captured, "level=ERROR messsage=foo\n").Test(t)
+
+		xycond.ExpectNil(xylog.ReplaceHandler(t.Name(), handler)).Test(t)
+		xycond.ExpectNil(xylog.ReplaceHandler(t.Name()+"-new", xylog.GetHandler(""))).
+			Test(t)
 	})
 }
+
+func TestReplaceHandlerWithNamedHandler(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xycond.ExpectPanic(xyerror.AssertionError, func() {
+		xylog.ReplaceHandler(t.Name(), xylog.GetHandler(t.Name()+"-other"))
+	}).Test(t)
+}
+
+func TestHandlers(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xylog.Reset()
+	var b, a = xylog.GetHandler("b"), xylog.GetHandler("a")
+	xylog.GetHandler("")
+	var handlers = xylog.Handlers()
+	xycond.ExpectEqual(len(handlers), 2).Test(t)
+	xycond.ExpectEqual(handlers[0], a).Test(t)
+	xycond.ExpectEqual(handlers[1], b).Test(t)
+}
+
+// closelessEmitter hides the Close method of an Emitter.
+type closelessEmitter struct {
+	xylog.Emitter
+}
+
+func TestHandlerConcurrentReconfiguration(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var handler = xylog.GetHandler("")
+		handler.AddEmitter(xylog.NewStreamEmitter(io.Discard))
+		logger.AddHandler(handler)
+
+		var done = make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				handler.AddField("i", i)
+				handler.AddMacro("level", "levelname")
+				handler.SetTimeLayout(time.RFC1123)
+				handler.SetEncoding(encoding.NewJSONEncoding())
+				handler.SetLevel(xylog.DEBUG)
+				var filter = &test.LoggerNameFilter{Name: t.Name()}
+				handler.AddFilter(filter)
+				handler.RemoveFilter(filter)
+			}
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					logger.Event("foo").Field("j", j).Error()
+				}
+			}()
+		}
+		wg.Wait()
+		<-done
+
+		xycond.ExpectEqual(len(handler.Filters()), 0).Test(t)
+	})
+}
+
+func TestHandlerReconfigurationIsolated(t *testing.T) {
+	var handler = xylog.GetHandler("")
+	handler.AddField("foo", "bar")
+	var emitters = handler.Emitters()
+	var filters = handler.Filters()
+
+	handler.AddEmitter(xylog.NewStreamEmitter(io.Discard))
+	handler.AddFilter(&test.LoggerNameFilter{})
+	xycond.ExpectEmpty(emitters).Test(t)
+	xycond.ExpectEmpty(filters).Test(t)
+	xycond.ExpectEqual(len(handler.Emitters()), 1).Test(t)
+	xycond.ExpectEqual(len(handler.Filters()), 1).Test(t)
+}
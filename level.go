@@ -0,0 +1,127 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/xybor-x/xyerror"
+)
+
+// levelAliases contains alternative names of levels, they are only used when
+// parsing levels.
+var levelAliases = map[string]int{
+	"WARN":  WARNING,
+	"FATAL": CRITICAL,
+}
+
+// Level is a logging level which can be converted from and to its name. It
+// implements encoding.TextMarshaler, encoding.TextUnmarshaler, json.Marshaler,
+// json.Unmarshaler and flag.Value, so levels can be read from configuration
+// files, environment variables or command line flags:
+//
+//	var level = xylog.Level(xylog.WARNING)
+//	flag.Var(&level, "level", "the logging level")
+//	flag.Parse()
+//	logger.SetLevel(int(level))
+type Level int
+
+// ParseLevel converts a level name, which is added by AddLevel or is an alias
+// (WARN, FATAL), to the Level. The name is case-insensitive. If the name is
+// unknown but it is an integer, the integer is used as the Level. If many
+// levels have the name, the lowest one is returned.
+func ParseLevel(s string) (Level, error) {
+	var name = strings.ToUpper(strings.TrimSpace(s))
+
+	var found = false
+	var result int
+	globalLock.RLock()
+	for level, levelName := range levelToName {
+		if strings.ToUpper(levelName) == name && (!found || level < result) {
+			found, result = true, level
+		}
+	}
+	globalLock.RUnlock()
+	if found {
+		return Level(result), nil
+	}
+
+	if level, ok := levelAliases[name]; ok {
+		return Level(level), nil
+	}
+
+	if level, err := strconv.Atoi(name); err == nil {
+		return Level(level), nil
+	}
+
+	return 0, xyerror.ValueError.Newf("unknown level %q", s)
+}
+
+// String returns the name of the Level. It returns the number as a string if
+// the Level has no name.
+func (l Level) String() string {
+	if name := GetLevelName(int(l)); name != "" {
+		return name
+	}
+	return strconv.Itoa(int(l))
+}
+
+// Set parses the string to the Level, see ParseLevel.
+func (l *Level) Set(s string) error {
+	var level, err = ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// MarshalText encodes the Level to its name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText parses the text to the Level, see ParseLevel.
+func (l *Level) UnmarshalText(text []byte) error {
+	return l.Set(string(text))
+}
+
+// MarshalJSON encodes the Level to a JSON string of its name.
+func (l Level) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// UnmarshalJSON parses a JSON string or number to the Level.
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return l.Set(s)
+	}
+
+	var level int
+	if err := json.Unmarshal(data, &level); err != nil {
+		return xyerror.ValueError.Newf("invalid level %s", data)
+	}
+	*l = Level(level)
+	return nil
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"sort"
+	"time"
+
+	"github.com/xybor-x/xycond"
+)
+
+// LevelOverride is a temporary level of a Logger set by Logger.SetLevelFor.
+type LevelOverride struct {
+	// Logger is the full name of the Logger.
+	Logger string
+
+	// Level is the temporary level.
+	Level int
+
+	// Previous is the level which is restored when the override expires.
+	Previous int
+
+	// Expires is the time when the override expires.
+	Expires time.Time
+}
+
+// levelOverride is an active LevelOverride with the function cancelling the
+// timer which ends it.
+type levelOverride struct {
+	LevelOverride
+	stop func() bool
+}
+
+// LevelOverrides returns all active temporary levels of Loggers in the default
+// Registry, sorted by Logger name.
+func LevelOverrides() []LevelOverride {
+	return defaultRegistry.LevelOverrides()
+}
+
+// LevelOverrides returns all active temporary levels of Loggers in this
+// Registry, sorted by Logger name.
+func (r *Registry) LevelOverrides() []LevelOverride {
+	r.lock.RLock()
+	var overrides = r.root.collectOverrides(nil)
+	r.lock.RUnlock()
+
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Logger < overrides[j].Logger
+	})
+	return overrides
+}
+
+// SetLevelFor sets a temporary logging level for the duration. When the
+// duration elapses on the Clock of the Registry (see TimerClock), the level
+// before the first active override is restored automatically. Calling
+// SetLevelFor again while an override is active replaces the temporary level
+// and the duration, but still restores the original level.
+//
+// SetLevel cancels the override and keeps the new level, RestoreLevel ends it
+// immediately.
+func (lg *Logger) SetLevelFor(level int, d time.Duration) {
+	xycond.AssertTrue(d > 0)
+
+	lg.lock.Lock()
+	defer lg.lock.Unlock()
+
+	var previous = lg.level
+	if lg.override != nil {
+		previous = lg.override.Previous
+		lg.stopOverride()
+	}
+
+	var clock = lg.registry.loadSettings().clock
+	var o = &levelOverride{LevelOverride: LevelOverride{
+		Logger:   lg.name,
+		Level:    level,
+		Previous: previous,
+		Expires:  clock.Now().Add(d),
+	}}
+	o.stop = afterFunc(clock, d, func() { lg.endOverride(o) })
+
+	lg.override = o
+	lg.level = level
+	invalidateCaches()
+}
+
+// RestoreLevel ends the temporary level set by SetLevelFor immediately and
+// restores the previous level. It does nothing if there is no active
+// override.
+func (lg *Logger) RestoreLevel() {
+	lg.lock.Lock()
+	defer lg.lock.Unlock()
+
+	if lg.override != nil {
+		lg.level = lg.override.Previous
+		lg.stopOverride()
+		invalidateCaches()
+	}
+}
+
+// endOverride restores the previous level if the override is still active. It
+// is called when the override expires.
+func (lg *Logger) endOverride(o *levelOverride) {
+	lg.lock.Lock()
+	defer lg.lock.Unlock()
+
+	if lg.override == o {
+		lg.level = o.Previous
+		lg.override = nil
+		invalidateCaches()
+	}
+}
+
+// stopOverride cancels the active override without changing the level. It
+// must be called while holding the lock of this Logger.
+func (lg *Logger) stopOverride() {
+	if lg.override != nil {
+		lg.override.stop()
+		lg.override = nil
+	}
+}
+
+// stopOverrides cancels active overrides of this Logger and all its
+// descendants without changing their levels.
+func (lg *Logger) stopOverrides() {
+	lg.lock.WLockFunc(lg.stopOverride)
+	for _, child := range lg.Children() {
+		child.stopOverrides()
+	}
+}
+
+// collectOverrides appends active overrides of this Logger and all its
+// descendants to the list.
+func (lg *Logger) collectOverrides(overrides []LevelOverride) []LevelOverride {
+	lg.lock.RLock()
+	if lg.override != nil {
+		overrides = append(overrides, lg.override.LevelOverride)
+	}
+	var children = make([]*Logger, 0, len(lg.children))
+	for _, child := range lg.children {
+		children = append(children, child)
+	}
+	lg.lock.RUnlock()
+
+	for i := range children {
+		overrides = children[i].collectOverrides(overrides)
+	}
+	return overrides
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+// newFakeRegistry creates a Registry using a FakeClock.
+func newFakeRegistry() (*xylog.Registry, *test.FakeClock) {
+	var registry = xylog.NewRegistry()
+	var clock = test.NewFakeClock(time.Unix(100, 0))
+	registry.SetClock(clock)
+	return registry, clock
+}
+
+func TestLoggerSetLevelForExpires(t *testing.T) {
+	var registry, clock = newFakeRegistry()
+	var lg = registry.GetLogger("payments")
+	lg.SetLevel(xylog.ERROR)
+
+	lg.SetLevelFor(xylog.DEBUG, time.Minute)
+	xycond.ExpectEqual(lg.Level(), xylog.DEBUG).Test(t)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+
+	clock.Advance(time.Minute - time.Second)
+	xycond.ExpectEqual(lg.Level(), xylog.DEBUG).Test(t)
+
+	clock.Advance(time.Second)
+	xycond.ExpectEqual(lg.Level(), xylog.ERROR).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.WARNING)).Test(t)
+	xycond.ExpectEmpty(registry.LevelOverrides()).Test(t)
+}
+
+func TestLoggerSetLevelForTwice(t *testing.T) {
+	var registry, clock = newFakeRegistry()
+	var lg = registry.GetLogger("payments")
+	lg.SetLevel(xylog.ERROR)
+
+	lg.SetLevelFor(xylog.INFO, time.Hour)
+	lg.SetLevelFor(xylog.DEBUG, time.Minute)
+	xycond.ExpectEqual(lg.Level(), xylog.DEBUG).Test(t)
+
+	var overrides = registry.LevelOverrides()
+	xycond.ExpectEqual(len(overrides), 1).Test(t)
+	xycond.ExpectEqual(overrides[0].Previous, xylog.ERROR).Test(t)
+
+	clock.Advance(time.Minute)
+	xycond.ExpectEqual(lg.Level(), xylog.ERROR).Test(t)
+}
+
+func TestLoggerSetLevelCancelsOverride(t *testing.T) {
+	var registry, clock = newFakeRegistry()
+	var lg = registry.GetLogger("payments")
+
+	lg.SetLevelFor(xylog.DEBUG, time.Minute)
+	lg.SetLevel(xylog.INFO)
+	xycond.ExpectEmpty(registry.LevelOverrides()).Test(t)
+
+	clock.Advance(time.Hour)
+	xycond.ExpectEqual(lg.Level(), xylog.INFO).Test(t)
+}
+
+func TestLoggerSetLevelForSystemClock(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments")
+	var before = time.Now()
+
+	lg.SetLevelFor(xylog.DEBUG, time.Hour)
+	var overrides = registry.LevelOverrides()
+	xycond.ExpectEqual(len(overrides), 1).Test(t)
+	xycond.ExpectFalse(overrides[0].Expires.Before(before.Add(time.Hour))).
+		Test(t)
+	lg.RestoreLevel()
+}
+
+func TestLoggerRestoreLevel(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments")
+
+	lg.RestoreLevel()
+	xycond.ExpectEqual(lg.Level(), xylog.NOTSET).Test(t)
+
+	lg.SetLevelFor(xylog.DEBUG, time.Hour)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+
+	lg.RestoreLevel()
+	xycond.ExpectEqual(lg.Level(), xylog.NOTSET).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectEmpty(registry.LevelOverrides()).Test(t)
+}
+
+func TestLevelOverrides(t *testing.T) {
+	var registry, clock = newFakeRegistry()
+	registry.GetLogger("b").SetLevelFor(xylog.DEBUG, time.Hour)
+	registry.GetLogger("a.c").SetLevel(xylog.ERROR)
+	registry.GetLogger("a.c").SetLevelFor(xylog.INFO, time.Minute)
+	registry.GetLogger("a.d").SetLevel(xylog.INFO)
+
+	var overrides = registry.LevelOverrides()
+	xycond.ExpectEqual(len(overrides), 2).Test(t)
+
+	xycond.ExpectEqual(overrides[0].Logger, "a.c").Test(t)
+	xycond.ExpectEqual(overrides[0].Level, xylog.INFO).Test(t)
+	xycond.ExpectEqual(overrides[0].Previous, xylog.ERROR).Test(t)
+	xycond.ExpectEqual(overrides[0].Expires, clock.Now().Add(time.Minute)).
+		Test(t)
+
+	xycond.ExpectEqual(overrides[1].Logger, "b").Test(t)
+	xycond.ExpectEqual(overrides[1].Level, xylog.DEBUG).Test(t)
+	xycond.ExpectEqual(overrides[1].Previous, xylog.NOTSET).Test(t)
+}
+
+func TestLevelOverrideRestore(t *testing.T) {
+	var lg = xylog.GetLogger(t.Name())
+	lg.SetLevel(xylog.ERROR)
+	defer lg.SetLevel(xylog.NOTSET)
+
+	lg.SetLevelFor(xylog.DEBUG, time.Hour)
+	var state = xylog.Snapshot()
+	xylog.Restore(state)
+
+	xycond.ExpectEqual(lg.Level(), xylog.ERROR).Test(t)
+	xycond.ExpectEmpty(xylog.LevelOverrides()).Test(t)
+}
+
+func TestLevelOverrideNotPruned(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments")
+	lg.SetLevelFor(xylog.NOTSET, time.Hour)
+
+	xycond.ExpectEqual(registry.PruneLoggers(-time.Hour), 0).Test(t)
+	lg.RestoreLevel()
+	xycond.ExpectEqual(registry.PruneLoggers(-time.Hour), 1).Test(t)
+}
+
+func TestLevelOverrideReset(t *testing.T) {
+	var registry, clock = newFakeRegistry()
+	var lg = registry.GetLogger("payments")
+	lg.SetLevel(xylog.ERROR)
+	lg.SetLevelFor(xylog.DEBUG, time.Minute)
+
+	registry.Reset()
+	xycond.ExpectEmpty(registry.LevelOverrides()).Test(t)
+
+	clock.Advance(time.Hour)
+	xycond.ExpectEqual(lg.Level(), xylog.DEBUG).Test(t)
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"path"
+	"sort"
+
+	"github.com/xybor-x/xyerror"
+)
+
+// LevelRule sets the level of all Loggers whose names match a pattern.
+type LevelRule struct {
+	// Pattern is matched against full names of Loggers by path.Match, so "*"
+	// matches any sequence of characters including dots.
+	Pattern string
+
+	// Level is the level of matched Loggers.
+	Level int
+}
+
+// SetLevelRule sets the level of all Loggers of the default Registry whose
+// names match the pattern, e.g. "payments.*" or "*.sql". The rule applies to
+// existing Loggers and to Loggers created later, so levels can be configured
+// before libraries create their Loggers.
+//
+// A rule only applies to Loggers with NOTSET level, a level set by
+// Logger.SetLevel always takes precedence. If many rules match a Logger, the
+// most specific one wins, that is the one having the most characters which are
+// not wildcards. Other Loggers still inherit the effective level of their
+// parent.
+//
+// A level set on an ancestor is as specific as the full name of the ancestor,
+// so it takes precedence over less specific rules matching its descendants.
+// E.g. if "payments.api" has ERROR level, "payments.*" does not apply to
+// "payments.api.v1", but "payments.api.*" does.
+//
+// Setting a rule with an existing pattern replaces its level.
+func SetLevelRule(pattern string, level int) error {
+	return defaultRegistry.SetLevelRule(pattern, level)
+}
+
+// RemoveLevelRule removes the rule with the pattern from the default Registry.
+func RemoveLevelRule(pattern string) {
+	defaultRegistry.RemoveLevelRule(pattern)
+}
+
+// LevelRules returns all rules of the default Registry, the most specific rule
+// first.
+func LevelRules() []LevelRule {
+	return defaultRegistry.LevelRules()
+}
+
+// SetLevelRule sets the level of all Loggers of this Registry whose names
+// match the pattern. See the package-level SetLevelRule for details.
+func (r *Registry) SetLevelRule(pattern string, level int) error {
+	if pattern == "" {
+		return xyerror.ValueError.New("empty level rule pattern")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return xyerror.ValueError.Newf("invalid level rule pattern %q", pattern)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var old = r.loadLevelRules().rules
+	var rules = make([]LevelRule, 0, len(old)+1)
+	for _, rule := range old {
+		if rule.Pattern != pattern {
+			rules = append(rules, rule)
+		}
+	}
+	rules = append(rules, LevelRule{Pattern: pattern, Level: level})
+	sortLevelRules(rules)
+
+	r.levelRules.Store(&levelRuleSet{rules: rules})
+	invalidateCaches()
+	return nil
+}
+
+// RemoveLevelRule removes the rule with the pattern from this Registry.
+func (r *Registry) RemoveLevelRule(pattern string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var old = r.loadLevelRules().rules
+	var rules []LevelRule
+	for _, rule := range old {
+		if rule.Pattern != pattern {
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(rules) != len(old) {
+		r.levelRules.Store(&levelRuleSet{rules: rules})
+		invalidateCaches()
+	}
+}
+
+// LevelRules returns all rules of this Registry, the most specific rule first.
+func (r *Registry) LevelRules() []LevelRule {
+	return append([]LevelRule(nil), r.loadLevelRules().rules...)
+}
+
+// loadLevelRules returns the current level rules of this Registry.
+func (r *Registry) loadLevelRules() *levelRuleSet {
+	return r.levelRules.Load().(*levelRuleSet)
+}
+
+// levelRuleSet is a list of LevelRules sorted by specificity, the most specific
+// rule first. It is never modified after being stored in a Registry.
+type levelRuleSet struct {
+	rules []LevelRule
+}
+
+// match returns the most specific rule matching the logger name. It returns
+// false if no rule matches.
+func (set *levelRuleSet) match(name string) (LevelRule, bool) {
+	for _, rule := range set.rules {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			return rule, true
+		}
+	}
+	return LevelRule{}, false
+}
+
+// ruleMatch is the level and the specificity of the rule matched by a Logger
+// in a levelRuleSet.
+type ruleMatch struct {
+	set         *levelRuleSet
+	level       int
+	specificity int
+}
+
+// ruleLevel returns the level and the specificity of the most specific rule
+// matching the name of this Logger, or NOTSET if no rule matches. The rules
+// are only matched once for each levelRuleSet.
+func (lg *Logger) ruleLevel() (int, int) {
+	var set = lg.registry.loadLevelRules()
+	if len(set.rules) == 0 {
+		return NOTSET, 0
+	}
+
+	if m, ok := lg.rule.Load().(*ruleMatch); ok && m.set == set {
+		return m.level, m.specificity
+	}
+
+	var m = &ruleMatch{set: set, level: NOTSET}
+	if rule, ok := set.match(lg.Name()); ok {
+		m.level, m.specificity = rule.Level, specificity(rule.Pattern)
+	}
+	lg.rule.Store(m)
+	return m.level, m.specificity
+}
+
+// sortLevelRules sorts rules by specificity, the most specific rule first.
+// Rules with the same specificity are sorted by pattern.
+func sortLevelRules(rules []LevelRule) {
+	sort.Slice(rules, func(i, j int) bool {
+		var si, sj = specificity(rules[i].Pattern), specificity(rules[j].Pattern)
+		if si != sj {
+			return si > sj
+		}
+		return rules[i].Pattern < rules[j].Pattern
+	})
+}
+
+// specificity returns the number of characters which are not wildcards in the
+// pattern. A character class counts as one character.
+func specificity(pattern string) int {
+	var n int
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?':
+		case '\\':
+			i++
+			n++
+		case '[':
+			for i < len(pattern) && pattern[i] != ']' {
+				i++
+			}
+			n++
+		default:
+			n++
+		}
+	}
+	return n
+}
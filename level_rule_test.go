@@ -0,0 +1,160 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"testing"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+)
+
+func TestLevelRuleExistingAndFutureLoggers(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var existing = registry.GetLogger("payments.api")
+	xycond.ExpectFalse(existing.Enabled(xylog.DEBUG)).Test(t)
+
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(existing.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(registry.GetLogger("payments.db").Enabled(xylog.DEBUG)).
+		Test(t)
+	xycond.ExpectFalse(registry.GetLogger("payments").Enabled(xylog.DEBUG)).
+		Test(t)
+	xycond.ExpectFalse(registry.GetLogger("orders").Enabled(xylog.DEBUG)).
+		Test(t)
+}
+
+func TestLevelRulePrecedence(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).Test(t)
+	xycond.ExpectNil(registry.SetLevelRule("payments.api.*", xylog.ERROR)).Test(t)
+	xycond.ExpectNil(registry.SetLevelRule("*.sql", xylog.CRITICAL)).Test(t)
+
+	var cases = map[string]int{
+		"payments.api.v1": xylog.ERROR,
+		"payments.sql":    xylog.DEBUG,
+		"orders.sql":      xylog.CRITICAL,
+		"orders":          xylog.WARNING,
+	}
+	for name, level := range cases {
+		var lg = registry.GetLogger(name)
+		xycond.ExpectTrue(lg.Enabled(level)).Test(t)
+		xycond.ExpectFalse(lg.Enabled(level - 1)).Test(t)
+	}
+
+	var rules = registry.LevelRules()
+	xycond.ExpectEqual(len(rules), 3).Test(t)
+	xycond.ExpectEqual(rules[0].Pattern, "payments.api.*").Test(t)
+	xycond.ExpectEqual(rules[1].Pattern, "payments.*").Test(t)
+	xycond.ExpectEqual(rules[2].Pattern, "*.sql").Test(t)
+}
+
+func TestLevelRuleExplicitLevel(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments.api")
+	lg.SetLevel(xylog.ERROR)
+
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.WARNING)).Test(t)
+
+	lg.SetLevel(xylog.NOTSET)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+}
+
+func TestLevelRuleExplicitAncestorLevel(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments.api.v1")
+	registry.GetLogger("payments.api").SetLevel(xylog.ERROR)
+
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.WARNING)).Test(t)
+
+	xycond.ExpectNil(registry.SetLevelRule("payments.api.*", xylog.DEBUG)).
+		Test(t)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+}
+
+func TestLevelRuleInherited(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	xycond.ExpectNil(registry.SetLevelRule("payments", xylog.INFO)).Test(t)
+	xycond.ExpectTrue(registry.GetLogger("payments.api").Enabled(xylog.INFO)).
+		Test(t)
+}
+
+func TestLevelRuleReplaceAndRemove(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments.api")
+
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).Test(t)
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.INFO)).Test(t)
+	xycond.ExpectEqual(len(registry.LevelRules()), 1).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(lg.Enabled(xylog.INFO)).Test(t)
+
+	registry.RemoveLevelRule("payments.*")
+	xycond.ExpectEmpty(registry.LevelRules()).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.INFO)).Test(t)
+}
+
+func TestLevelRuleError(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	for _, pattern := range []string{"", "[a"} {
+		xycond.ExpectError(registry.SetLevelRule(pattern, xylog.DEBUG),
+			xyerror.ValueError).Test(t)
+	}
+	xycond.ExpectEmpty(registry.LevelRules()).Test(t)
+}
+
+func TestLevelRuleRestore(t *testing.T) {
+	var lg = xylog.GetLogger(t.Name())
+	var state = xylog.Snapshot()
+	xycond.ExpectNil(xylog.SetLevelRule(t.Name(), xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectEqual(len(xylog.LevelRules()), 1).Test(t)
+
+	xylog.Restore(state)
+	xycond.ExpectEmpty(xylog.LevelRules()).Test(t)
+	xycond.ExpectFalse(lg.Enabled(xylog.DEBUG)).Test(t)
+}
+
+func TestLevelRuleConcurrently(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("payments.api")
+
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			lg.Enabled(xylog.DEBUG)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).
+			Test(t)
+		registry.RemoveLevelRule("payments.*")
+	}
+	<-done
+
+	xycond.ExpectFalse(lg.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectNil(registry.SetLevelRule("payments.*", xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+}
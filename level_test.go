@@ -0,0 +1,126 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+)
+
+func TestParseLevel(t *testing.T) {
+	xylog.AddLevel(121, "CUSTOM")
+
+	var cases = map[string]xylog.Level{
+		"debug":    xylog.DEBUG,
+		"Info":     xylog.INFO,
+		"WARNING":  xylog.WARNING,
+		"warn":     xylog.WARNING,
+		" error ":  xylog.ERROR,
+		"critical": xylog.CRITICAL,
+		"fatal":    xylog.CRITICAL,
+		"notset":   xylog.NOTSET,
+		"custom":   121,
+		"15":       15,
+		"-1":       -1,
+	}
+
+	for s, expected := range cases {
+		var level, err = xylog.ParseLevel(s)
+		xycond.ExpectNil(err).Test(t)
+		xycond.ExpectEqual(level, expected).Test(t)
+	}
+}
+
+func TestParseLevelDuplicateName(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xylog.AddLevel(135, "DUPLICATE")
+	xylog.AddLevel(133, "duplicate")
+	xylog.AddLevel(134, "Duplicate")
+	for i := 0; i < 10; i++ {
+		var level, err = xylog.ParseLevel("duplicate")
+		xycond.ExpectNil(err).Test(t)
+		xycond.ExpectEqual(level, xylog.Level(133)).Test(t)
+	}
+}
+
+func TestParseLevelError(t *testing.T) {
+	for _, s := range []string{"", "foo", "1.5"} {
+		var _, err = xylog.ParseLevel(s)
+		xycond.ExpectError(err, xyerror.ValueError).Test(t)
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	xylog.AddLevel(122, "CUSTOM2")
+	xycond.ExpectEqual(xylog.Level(xylog.INFO).String(), "INFO").Test(t)
+	xycond.ExpectEqual(xylog.Level(122).String(), "CUSTOM2").Test(t)
+	xycond.ExpectEqual(xylog.Level(123).String(), "123").Test(t)
+}
+
+func TestLevelText(t *testing.T) {
+	var text, err = xylog.Level(xylog.ERROR).MarshalText()
+	xycond.ExpectNil(err).Test(t)
+	xycond.ExpectEqual(string(text), "ERROR").Test(t)
+
+	var level xylog.Level
+	xycond.ExpectNil(level.UnmarshalText([]byte("warn"))).Test(t)
+	xycond.ExpectEqual(level, xylog.Level(xylog.WARNING)).Test(t)
+	xycond.ExpectError(level.UnmarshalText([]byte("foo")), xyerror.ValueError).
+		Test(t)
+	xycond.ExpectEqual(level, xylog.Level(xylog.WARNING)).Test(t)
+}
+
+func TestLevelJSON(t *testing.T) {
+	type config struct {
+		Level xylog.Level `json:"level"`
+	}
+
+	var data, err = json.Marshal(config{Level: xylog.DEBUG})
+	xycond.ExpectNil(err).Test(t)
+	xycond.ExpectEqual(string(data), `{"level":"DEBUG"}`).Test(t)
+
+	var cfg config
+	xycond.ExpectNil(json.Unmarshal([]byte(`{"level":"info"}`), &cfg)).Test(t)
+	xycond.ExpectEqual(cfg.Level, xylog.Level(xylog.INFO)).Test(t)
+
+	xycond.ExpectNil(json.Unmarshal([]byte(`{"level":35}`), &cfg)).Test(t)
+	xycond.ExpectEqual(cfg.Level, xylog.Level(35)).Test(t)
+
+	xycond.ExpectNotNil(json.Unmarshal([]byte(`{"level":"foo"}`), &cfg)).Test(t)
+	xycond.ExpectNotNil(json.Unmarshal([]byte(`{"level":true}`), &cfg)).Test(t)
+}
+
+func TestLevelFlag(t *testing.T) {
+	var level = xylog.Level(xylog.WARNING)
+	var fs = flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	fs.Var(&level, "level", "the logging level")
+
+	xycond.ExpectNil(fs.Parse([]string{"-level", "debug"})).Test(t)
+	xycond.ExpectEqual(level, xylog.Level(xylog.DEBUG)).Test(t)
+	xycond.ExpectEqual(fs.Lookup("level").Value.String(), "DEBUG").Test(t)
+}
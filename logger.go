@@ -21,13 +21,17 @@
 package xylog
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime/debug"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/xybor-x/xycond"
 	"github.com/xybor-x/xylock"
+	"github.com/xybor-x/xylog/encoding"
 )
 
 // Logger represents a single logging channel. A "logging channel" indicates an
@@ -42,16 +46,55 @@ import (
 // "input.gnu" for the sub-levels. There is no arbitrary limit to the depth of
 // nesting.
 type Logger struct {
+	*loggerCore
+
+	// extra contains fields of a Logger derived by With, it is never modified
+	// after the Logger is created. The first encodedLen fields have no lazy
+	// value, so encoded caches them after being encoded by each Encoding.
+	extra      []field
+	encodedLen int
+	encoded    encoding.Fields
+}
+
+// loggerCore is the state of a Logger in the hierarchy. It is shared by the
+// Logger and all Loggers derived from it.
+type loggerCore struct {
+	// lastUsed is the time in nanoseconds when the Logger was got or logged
+	// for the last time. It is accessed atomically, so it is placed first to
+	// be 64-bit aligned.
+	lastUsed int64
+
+	// levelCache caches the effective level, the disabled flag and the
+	// generation when they were computed. It is accessed atomically, see
+	// packLevelCache for its layout.
+	levelCache uint64
+
+	// chain caches the Handlers which handle records of this Logger, see
+	// handlerChain.
+	chain atomic.Value
+
+	// rule caches the level rule matching this Logger, see ruleMatch.
+	rule atomic.Value
+
 	f *filterer
 
-	name     string
-	children map[string]*Logger
-	parent   *Logger
-	level    int
-	handlers []*Handler
-	lock     *xylock.RWLock
-	cache    map[int]bool
-	fields   []field
+	// registry is the Registry which this Logger belongs to. It is never
+	// changed after the Logger is created.
+	registry *Registry
+
+	// override is the active temporary level set by SetLevelFor, it is nil
+	// if there is no override.
+	override *levelOverride
+
+	name      string
+	children  map[string]*Logger
+	parent    *Logger
+	level     int
+	handlers  []*Handler
+	lock      *xylock.RWLock
+	fields    []field
+	propagate bool
+	disabled  bool
 }
 
 // GetLogger gets a logger with the specified name, creating it if it doesn't
@@ -60,21 +103,34 @@ type Logger struct {
 //
 // Leave name as empty string to get the root logger.
 func GetLogger(name string) *Logger {
-	if name == "" {
-		return rootLogger
-	}
+	return defaultRegistry.GetLogger(name)
+}
 
-	globalLock.Lock()
-	defer globalLock.Unlock()
+// RemoveLogger removes the logger with the specified name and all its
+// descendants from the logger hierarchy. The removed loggers keep their parent,
+// so they still inherit the level and handlers of their ancestors if they are
+// held and used. A later GetLogger call with the same name creates a new
+// logger.
+//
+// The root logger can not be removed.
+func RemoveLogger(name string) {
+	defaultRegistry.RemoveLogger(name)
+}
 
-	var lg = rootLogger
-	for _, part := range strings.Split(name, ".") {
-		if _, ok := lg.children[part]; !ok {
-			lg.children[part] = newLogger(part, lg)
-		}
-		lg = lg.children[part]
-	}
-	return lg
+// PruneLoggers removes idle leaf loggers from the logger hierarchy and returns
+// the number of removed loggers. A logger is idle if it has been neither got
+// by GetLogger nor logged for the given duration, and it is not configured (no
+// handler, no filter, no field, NOTSET level, propagating, and not disabled).
+// After a leaf is removed, its parent may become an idle leaf and be removed
+// too. Like RemoveLogger, the removed loggers keep their parent.
+func PruneLoggers(idle time.Duration) int {
+	return defaultRegistry.PruneLoggers(idle)
+}
+
+// SetAutoPrune starts pruning idle leaf loggers periodically as PruneLoggers
+// does. Use a non-positive duration to stop it.
+func SetAutoPrune(idle time.Duration) {
+	defaultRegistry.SetAutoPrune(idle)
 }
 
 // Name returns the full name.
@@ -109,23 +165,66 @@ func (lg *Logger) Level() int {
 	return lg.level
 }
 
-// SetLevel sets the new logging level.
+// SetLevel sets the new logging level. It cancels the temporary level set by
+// SetLevelFor.
 func (lg *Logger) SetLevel(level int) {
-	lg.lock.WLockFunc(func() { lg.level = level })
-	rootLogger.clearCache()
+	lg.lock.WLockFunc(func() {
+		lg.stopOverride()
+		lg.level = level
+	})
+	invalidateCaches()
+}
+
+// Propagate returns whether records logged by this Logger are passed to the
+// Handlers of its ancestors.
+func (lg *Logger) Propagate() bool {
+	lg.lock.RLock()
+	defer lg.lock.RUnlock()
+	return lg.propagate
+}
+
+// SetPropagate decides whether records logged by this Logger are passed to the
+// Handlers of its ancestors. It is true by default. When it is false, records
+// are only handled by Handlers of this Logger and its descendants.
+func (lg *Logger) SetPropagate(b bool) {
+	lg.lock.WLockFunc(func() { lg.propagate = b })
+	invalidateCaches()
+}
+
+// Disabled returns whether this Logger is disabled.
+func (lg *Logger) Disabled() bool {
+	lg.lock.RLock()
+	defer lg.lock.RUnlock()
+	return lg.disabled
 }
 
-// Handlers returns all current Handlers.
+// SetDisabled with true to silence this Logger completely, regardless of its
+// level. It does not affect other loggers, including its descendants.
+func (lg *Logger) SetDisabled(b bool) {
+	lg.lock.WLockFunc(func() { lg.disabled = b })
+	invalidateCaches()
+}
+
+// Handlers returns a copy of all current Handlers.
 func (lg *Logger) Handlers() []*Handler {
 	lg.lock.RLock()
 	defer lg.lock.RUnlock()
-	return lg.handlers
+	return append([]*Handler(nil), lg.handlers...)
 }
 
 // AddHandler adds a new handler.
 func (lg *Logger) AddHandler(h *Handler) {
 	xycond.AssertNotNil(h)
-	lg.lock.WLockFunc(func() { lg.handlers = append(lg.handlers, h) })
+
+	lg.lock.Lock()
+	defer lg.lock.Unlock()
+
+	// The handler list is copied on write, so the lists used by
+	// computeHandlerChain are never modified.
+	var handlers = make([]*Handler, 0, len(lg.handlers)+1)
+	handlers = append(handlers, lg.handlers...)
+	lg.handlers = append(handlers, h)
+	invalidateCaches()
 }
 
 // RemoveHandler removes an existed Handler.
@@ -133,18 +232,22 @@ func (lg *Logger) RemoveHandler(h *Handler) {
 	lg.lock.Lock()
 	defer lg.lock.Unlock()
 
+	var handlers []*Handler
 	for i := range lg.handlers {
-		if lg.handlers[i] == h {
-			lg.handlers = append(lg.handlers[:i], lg.handlers[i+1:]...)
+		if lg.handlers[i] != h {
+			handlers = append(handlers, lg.handlers[i])
 		}
 	}
+	lg.handlers = handlers
+	invalidateCaches()
 }
 
 // RemoveAllHandlers removes all existed Handlers.
 func (lg *Logger) RemoveAllHandlers() {
 	lg.lock.Lock()
 	defer lg.lock.Unlock()
-	lg.handlers = lg.handlers[:0]
+	lg.handlers = nil
+	invalidateCaches()
 }
 
 // Filters returns all current Filters.
@@ -174,87 +277,115 @@ func (lg *Logger) AddField(key string, value any) {
 	})
 }
 
+// Trace logs default formatting objects with TRACE level.
+func (lg *Logger) Trace(s string) {
+	if lg.isEnabledFor(TRACE) {
+		lg.log(nil, TRACE, makeField("messsage", s))
+	}
+}
+
+// Tracef logs a formatting message with TRACE level.
+func (lg *Logger) Tracef(s string, a ...any) {
+	if lg.isEnabledFor(TRACE) {
+		lg.log(nil, TRACE, makeField("messsage", fmt.Sprintf(s, a...)))
+	}
+}
+
 // Debug logs default formatting objects with DEBUG level.
 func (lg *Logger) Debug(s string) {
 	if lg.isEnabledFor(DEBUG) {
-		lg.log(DEBUG, makeField("messsage", s))
+		lg.log(nil, DEBUG, makeField("messsage", s))
 	}
 }
 
 // Debugf logs a formatting message with DEBUG level.
 func (lg *Logger) Debugf(s string, a ...any) {
 	if lg.isEnabledFor(DEBUG) {
-		lg.log(DEBUG, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, DEBUG, makeField("messsage", fmt.Sprintf(s, a...)))
 	}
 }
 
 // Info logs default formatting objects with INFO level.
 func (lg *Logger) Info(s string) {
 	if lg.isEnabledFor(INFO) {
-		lg.log(INFO, makeField("messsage", s))
+		lg.log(nil, INFO, makeField("messsage", s))
 	}
 }
 
 // Infof logs a formatting message with INFO level.
 func (lg *Logger) Infof(s string, a ...any) {
 	if lg.isEnabledFor(INFO) {
-		lg.log(INFO, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, INFO, makeField("messsage", fmt.Sprintf(s, a...)))
+	}
+}
+
+// Notice logs default formatting objects with NOTICE level.
+func (lg *Logger) Notice(s string) {
+	if lg.isEnabledFor(NOTICE) {
+		lg.log(nil, NOTICE, makeField("messsage", s))
+	}
+}
+
+// Noticef logs a formatting message with NOTICE level.
+func (lg *Logger) Noticef(s string, a ...any) {
+	if lg.isEnabledFor(NOTICE) {
+		lg.log(nil, NOTICE, makeField("messsage", fmt.Sprintf(s, a...)))
 	}
 }
 
 // Warn logs default formatting objects with WARN level.
 func (lg *Logger) Warn(s string) {
 	if lg.isEnabledFor(WARN) {
-		lg.log(WARN, makeField("messsage", s))
+		lg.log(nil, WARN, makeField("messsage", s))
 	}
 }
 
 // Warnf logs a formatting message with WARN level.
 func (lg *Logger) Warnf(s string, a ...any) {
 	if lg.isEnabledFor(WARN) {
-		lg.log(WARN, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, WARN, makeField("messsage", fmt.Sprintf(s, a...)))
 	}
 }
 
 // Warning logs default formatting objects with WARNING level.
 func (lg *Logger) Warning(s string) {
 	if lg.isEnabledFor(WARNING) {
-		lg.log(WARNING, makeField("messsage", s))
+		lg.log(nil, WARNING, makeField("messsage", s))
 	}
 }
 
 // Warningf logs a formatting message with WARNING level.
 func (lg *Logger) Warningf(s string, a ...any) {
 	if lg.isEnabledFor(WARNING) {
-		lg.log(WARNING, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, WARNING, makeField("messsage", fmt.Sprintf(s, a...)))
 	}
 }
 
 // Error logs default formatting objects with ERROR level.
 func (lg *Logger) Error(s string) {
 	if lg.isEnabledFor(ERROR) {
-		lg.log(ERROR, makeField("messsage", s))
+		lg.log(nil, ERROR, makeField("messsage", s))
 	}
 }
 
 // Errorf logs a formatting message with ERROR level.
 func (lg *Logger) Errorf(s string, a ...any) {
 	if lg.isEnabledFor(ERROR) {
-		lg.log(ERROR, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, ERROR, makeField("messsage", fmt.Sprintf(s, a...)))
 	}
 }
 
 // Critical logs default formatting objects with CRITICAL level.
 func (lg *Logger) Critical(s string) {
 	if lg.isEnabledFor(CRITICAL) {
-		lg.log(CRITICAL, makeField("messsage", s))
+		lg.log(nil, CRITICAL, makeField("messsage", s))
 	}
 }
 
 // Criticalf logs a formatting message with CRITICAL level.
 func (lg *Logger) Criticalf(s string, a ...any) {
 	if lg.isEnabledFor(CRITICAL) {
-		lg.log(CRITICAL, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, CRITICAL, makeField("messsage", fmt.Sprintf(s, a...)))
 	}
 }
 
@@ -290,7 +421,7 @@ func (lg *Logger) Panicf(s string, a ...any) {
 func (lg *Logger) Log(level int, s string) {
 	level = CheckLevel(level)
 	if lg.isEnabledFor(level) {
-		lg.log(level, makeField("messsage", s))
+		lg.log(nil, level, makeField("messsage", s))
 	}
 }
 
@@ -298,7 +429,250 @@ func (lg *Logger) Log(level int, s string) {
 func (lg *Logger) Logf(level int, s string, a ...any) {
 	level = CheckLevel(level)
 	if lg.isEnabledFor(level) {
-		lg.log(level, makeField("messsage", fmt.Sprintf(s, a...)))
+		lg.log(nil, level, makeField("messsage", fmt.Sprintf(s, a...)))
+	}
+}
+
+// TraceContext logs default formatting objects with TRACE level and fields
+// extracted from the context.
+func (lg *Logger) TraceContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(TRACE) {
+		lg.log(ctx, TRACE, makeField("messsage", s))
+	}
+}
+
+// DebugContext logs default formatting objects with DEBUG level and fields
+// extracted from the context.
+func (lg *Logger) DebugContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(DEBUG) {
+		lg.log(ctx, DEBUG, makeField("messsage", s))
+	}
+}
+
+// InfoContext logs default formatting objects with INFO level and fields
+// extracted from the context.
+func (lg *Logger) InfoContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(INFO) {
+		lg.log(ctx, INFO, makeField("messsage", s))
+	}
+}
+
+// NoticeContext logs default formatting objects with NOTICE level and fields
+// extracted from the context.
+func (lg *Logger) NoticeContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(NOTICE) {
+		lg.log(ctx, NOTICE, makeField("messsage", s))
+	}
+}
+
+// WarnContext logs default formatting objects with WARN level and fields
+// extracted from the context.
+func (lg *Logger) WarnContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(WARN) {
+		lg.log(ctx, WARN, makeField("messsage", s))
+	}
+}
+
+// WarningContext logs default formatting objects with WARNING level and fields
+// extracted from the context.
+func (lg *Logger) WarningContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(WARNING) {
+		lg.log(ctx, WARNING, makeField("messsage", s))
+	}
+}
+
+// ErrorContext logs default formatting objects with ERROR level and fields
+// extracted from the context.
+func (lg *Logger) ErrorContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(ERROR) {
+		lg.log(ctx, ERROR, makeField("messsage", s))
+	}
+}
+
+// CriticalContext logs default formatting objects with CRITICAL level and
+// fields extracted from the context.
+func (lg *Logger) CriticalContext(ctx context.Context, s string) {
+	if lg.isEnabledFor(CRITICAL) {
+		lg.log(ctx, CRITICAL, makeField("messsage", s))
+	}
+}
+
+// LogContext logs default formatting objects with a custom level and fields
+// extracted from the context.
+func (lg *Logger) LogContext(ctx context.Context, level int, s string) {
+	level = CheckLevel(level)
+	if lg.isEnabledFor(level) {
+		lg.log(ctx, level, makeField("messsage", s))
+	}
+}
+
+// Tracew logs a message and loosely typed key-value pairs with TRACE level.
+func (lg *Logger) Tracew(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(TRACE) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, TRACE, e.fields...)
+	}
+}
+
+// Debugw logs a message and loosely typed key-value pairs with DEBUG level.
+func (lg *Logger) Debugw(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(DEBUG) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, DEBUG, e.fields...)
+	}
+}
+
+// Infow logs a message and loosely typed key-value pairs with INFO level.
+func (lg *Logger) Infow(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(INFO) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, INFO, e.fields...)
+	}
+}
+
+// Noticew logs a message and loosely typed key-value pairs with NOTICE level.
+func (lg *Logger) Noticew(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(NOTICE) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, NOTICE, e.fields...)
+	}
+}
+
+// Warnw logs a message and loosely typed key-value pairs with WARN level.
+func (lg *Logger) Warnw(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(WARN) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, WARN, e.fields...)
+	}
+}
+
+// Warningw logs a message and loosely typed key-value pairs with WARNING level.
+func (lg *Logger) Warningw(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(WARNING) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, WARNING, e.fields...)
+	}
+}
+
+// Errorw logs a message and loosely typed key-value pairs with ERROR level.
+func (lg *Logger) Errorw(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(ERROR) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, ERROR, e.fields...)
+	}
+}
+
+// Criticalw logs a message and loosely typed key-value pairs with CRITICAL
+// level.
+func (lg *Logger) Criticalw(s string, keysAndValues ...any) {
+	if lg.isEnabledFor(CRITICAL) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, CRITICAL, e.fields...)
+	}
+}
+
+// Fatalw logs a message and loosely typed key-value pairs with CRITICAL level,
+// then followed by a call to os.Exit(1).
+func (lg *Logger) Fatalw(s string, keysAndValues ...any) {
+	lg.Criticalw(s, keysAndValues...)
+	os.Exit(1)
+}
+
+// Panicw logs a message and loosely typed key-value pairs with CRITICAL level,
+// then followed by a call to panic().
+func (lg *Logger) Panicw(s string, keysAndValues ...any) {
+	lg.Criticalw(s, keysAndValues...)
+	panic(s)
+}
+
+// Logw logs a message and loosely typed key-value pairs with a custom level.
+func (lg *Logger) Logw(level int, s string, keysAndValues ...any) {
+	level = CheckLevel(level)
+	if lg.isEnabledFor(level) {
+		var e = lg.sugar(s, keysAndValues)
+		defer e.free()
+		lg.log(nil, level, e.fields...)
+	}
+}
+
+// TraceFn logs a lazily computed message with TRACE level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) TraceFn(f func() string) {
+	if lg.isEnabledFor(TRACE) {
+		lg.log(nil, TRACE, makeField("messsage", lazyString(f)))
+	}
+}
+
+// DebugFn logs a lazily computed message with DEBUG level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) DebugFn(f func() string) {
+	if lg.isEnabledFor(DEBUG) {
+		lg.log(nil, DEBUG, makeField("messsage", lazyString(f)))
+	}
+}
+
+// InfoFn logs a lazily computed message with INFO level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) InfoFn(f func() string) {
+	if lg.isEnabledFor(INFO) {
+		lg.log(nil, INFO, makeField("messsage", lazyString(f)))
+	}
+}
+
+// NoticeFn logs a lazily computed message with NOTICE level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) NoticeFn(f func() string) {
+	if lg.isEnabledFor(NOTICE) {
+		lg.log(nil, NOTICE, makeField("messsage", lazyString(f)))
+	}
+}
+
+// WarnFn logs a lazily computed message with WARN level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) WarnFn(f func() string) {
+	if lg.isEnabledFor(WARN) {
+		lg.log(nil, WARN, makeField("messsage", lazyString(f)))
+	}
+}
+
+// WarningFn logs a lazily computed message with WARNING level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) WarningFn(f func() string) {
+	if lg.isEnabledFor(WARNING) {
+		lg.log(nil, WARNING, makeField("messsage", lazyString(f)))
+	}
+}
+
+// ErrorFn logs a lazily computed message with ERROR level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) ErrorFn(f func() string) {
+	if lg.isEnabledFor(ERROR) {
+		lg.log(nil, ERROR, makeField("messsage", lazyString(f)))
+	}
+}
+
+// CriticalFn logs a lazily computed message with CRITICAL level. The function
+// is only called if the message is logged by at least one Handler.
+func (lg *Logger) CriticalFn(f func() string) {
+	if lg.isEnabledFor(CRITICAL) {
+		lg.log(nil, CRITICAL, makeField("messsage", lazyString(f)))
+	}
+}
+
+// LogFn logs a lazily computed message with a custom level. The function is
+// only called if the message is logged by at least one Handler.
+func (lg *Logger) LogFn(level int, f func() string) {
+	level = CheckLevel(level)
+	if lg.isEnabledFor(level) {
+		lg.log(nil, level, makeField("messsage", lazyString(f)))
 	}
 }
 
@@ -308,8 +682,43 @@ func (lg *Logger) Stack(level int) {
 	var lines = strings.Split(s, "\n")
 
 	for i := range lines {
-		lg.log(level, makeField("stack", strings.TrimSpace(lines[i])))
+		lg.log(nil, level, makeField("stack", strings.TrimSpace(lines[i])))
+	}
+}
+
+// With creates a derived Logger carrying extra fields in form of key-value
+// pairs. The derived Logger shares the name, level, handlers, filters, and
+// fields with this Logger, but its extra fields never affect this Logger. It
+// is cheap and safe to create a derived Logger for every request.
+//
+// From the second record on, the extra fields are encoded only once by each
+// Encoding and reused, so their values must not be modified after this call.
+// Lazy values (func() any) are evaluated for every record and logged after the
+// other extra fields.
+//
+// Keys should be strings and each key should be followed by a value, other
+// values are logged under the "!BADKEY" key.
+func (lg *Logger) With(keysAndValues ...any) *Logger {
+	var parentEncoded = lg.encodedLen
+	var extra = make([]field, 0, len(lg.extra)+(len(keysAndValues)+1)/2)
+	extra = appendPairs(append(extra, lg.extra[:parentEncoded]...),
+		keysAndValues)
+
+	// Move lazy values behind the others, after the lazy values of this Logger.
+	var lazy []field
+	var n = parentEncoded
+	for i := parentEncoded; i < len(extra); i++ {
+		if isLazy(extra[i]) {
+			lazy = append(lazy, extra[i])
+		} else {
+			extra[n] = extra[i]
+			n++
+		}
 	}
+	extra = append(extra[:n], lg.extra[parentEncoded:]...)
+	extra = append(extra, lazy...)
+
+	return &Logger{loggerCore: lg.loggerCore, extra: extra, encodedLen: n}
 }
 
 // Event creates an EventLogger which logs key-value pairs.
@@ -320,11 +729,37 @@ func (lg *Logger) Event(e string) *EventLogger {
 	return elogger
 }
 
+// sugar takes an EventLogger from the pool and fills it with the message and
+// key-value pairs. The EventLogger must be freed after logging.
+func (lg *Logger) sugar(s string, keysAndValues []any) *EventLogger {
+	var e = eventLoggerPool.Get().(*EventLogger)
+	e.lg = lg
+	e.fields = append(e.fields, makeField("messsage", s))
+	e.fields = appendPairs(e.fields, keysAndValues)
+	return e
+}
+
 // log is a low-level logging method which creates a LogRecord and then calls
-// all the handlers of this logger to handle the record.
-func (lg *Logger) log(level int, fields ...field) {
+// all the handlers of this logger to handle the record. The ctx is nil if the
+// record is not logged with a context.
+func (lg *Logger) log(ctx context.Context, level int, fields ...field) {
 	fields = append(fields, lg.fields...)
-	var record = makeRecord(lg.name, level, fields...)
+	var extraAt = len(fields)
+	fields = append(fields, lg.extra...)
+	var contextAt = len(fields)
+	if ctx != nil {
+		fields = extractContext(ctx, fields)
+	}
+
+	var record = lg.registry.makeRecord(lg.name, level, fields...)
+	record.Context = ctx
+	if lg.encodedLen > 0 {
+		record.extra = &lg.encoded
+		record.extraAt = extraAt
+		record.extraLen = lg.encodedLen
+	}
+	record.contextLen = len(fields) - contextAt
+	lg.touch(record.Time)
 
 	if lg.filter(record) {
 		lg.callHandlers(record)
@@ -337,77 +772,242 @@ func (lg *Logger) filter(r LogRecord) bool {
 	return lg.lock.RLockFunc(func() any { return lg.f.filter(r) }).(bool)
 }
 
-// callHandlers passes a record to all relevant handlers.
-//
-// Loop through all handlers for this logger and its parents in the logger
-// hierarchy. If no handler was found, output a one-off error message to
-// os.Stderr.
+// callHandlers passes a record to all relevant handlers, see handlerChain.
 func (lg *Logger) callHandlers(record LogRecord) {
+	var handlers = lg.handlerChain()
+	for i := range handlers {
+		handlers[i].Handle(record)
+	}
+}
+
+// handlerChain returns all Handlers for this logger and its parents in the
+// logger hierarchy, stopping at the first logger whose propagate is false. A
+// Handler appears only once in the chain even if it is added to many loggers.
+//
+// The chain is computed once and cached until the hierarchy changes. The
+// returned slice must not be modified.
+func (lg *Logger) handlerChain() []*Handler {
+	var generation = currentGeneration()
+	if c, ok := lg.chain.Load().(*handlerChain); ok && c.generation == generation {
+		return c.handlers
+	}
+
+	var c = &handlerChain{generation: generation, handlers: lg.computeHandlerChain()}
+	lg.chain.Store(c)
+	return c.handlers
+}
+
+// computeHandlerChain loops through all handlers for this logger and its
+// parents to build a new Handler chain.
+func (lg *Logger) computeHandlerChain() []*Handler {
+	var chain []*Handler
 	var current = lg
 	for current != nil {
-		var handlers = current.Handlers()
+		current.lock.RLock()
+		var handlers, propagate, parent = current.handlers, current.propagate, current.parent
+		current.lock.RUnlock()
+
 		for i := range handlers {
-			handlers[i].Handle(record)
+			if !containsHandler(chain, handlers[i]) {
+				chain = append(chain, handlers[i])
+			}
 		}
-		current = current.Parent()
+		if !propagate {
+			break
+		}
+		current = parent
 	}
+	return chain
 }
 
-// isEnabledFor checks if a logging level should be logged in this logger.
+// Enabled checks if a logging level should be logged in this logger. Use it to
+// guard expensive computations of logging messages.
+func (lg *Logger) Enabled(level int) bool {
+	return lg.isEnabledFor(level)
+}
+
+// isEnabledFor checks if a logging level should be logged in this logger. It
+// takes no lock unless the cached effective level was invalidated.
 func (lg *Logger) isEnabledFor(level int) bool {
-	lg.lock.RLock()
-	var isEnabled, isCached = lg.cache[level]
-	lg.lock.RUnlock()
+	var cache = atomic.LoadUint64(&lg.levelCache)
+	if uint32(cache>>levelCacheGenerationShift) != currentGeneration() {
+		cache = lg.computeLevelCache()
+	}
 
-	if !isCached {
-		isEnabled = level >= lg.getEffectiveLevel()
-		lg.lock.WLockFunc(func() { lg.cache[level] = isEnabled })
+	if cache&levelCacheDisabledBit != 0 {
+		return false
 	}
-	return isEnabled
+	return level >= int(int32(cache))
+}
+
+// computeLevelCache computes the effective level and the disabled flag, then
+// stores them to levelCache with the current generation. If the generation
+// changes while computing, the stored value is invalid and will be computed
+// again at the next check.
+func (lg *Logger) computeLevelCache() uint64 {
+	var generation = currentGeneration()
+	var cache = packLevelCache(generation, lg.getEffectiveLevel(), lg.Disabled())
+	atomic.StoreUint64(&lg.levelCache, cache)
+	return cache
 }
 
 // getEffectiveLevel gets the effective level for this logger.
 //
 // Loop through this logger and its parents in the logger hierarchy, looking for
-// a non-zero logging level. Return the first one found.
+// a non-zero logging level. The first matching level rule found on the way is
+// returned instead if it is more specific than the logger having that level.
 func (lg *Logger) getEffectiveLevel() int {
-	var level, parent = lg.Level(), lg.Parent()
-	if level != NOTSET || parent == nil {
-		return level
+	var ruleLevel, ruleSpecificity = NOTSET, 0
+	for current := lg; current != nil; current = current.Parent() {
+		if level := current.Level(); level != NOTSET {
+			if ruleLevel != NOTSET && ruleSpecificity > len(current.Name()) {
+				return ruleLevel
+			}
+			return level
+		}
+		if ruleLevel == NOTSET {
+			ruleLevel, ruleSpecificity = current.ruleLevel()
+		}
 	}
-	return parent.getEffectiveLevel()
+	return ruleLevel
 }
 
-// clearCache clears logging level cache of this logger and all its children.
-func (lg *Logger) clearCache() {
-	lg.lock.WLockFunc(func() {
-		for k := range lg.cache {
-			delete(lg.cache, k)
+// handlerChain is an immutable Handler chain computed at a generation.
+type handlerChain struct {
+	generation uint32
+	handlers   []*Handler
+}
+
+// containsHandler checks if the Handler is in the list.
+func containsHandler(handlers []*Handler, h *Handler) bool {
+	for i := range handlers {
+		if handlers[i] == h {
+			return true
 		}
-	})
+	}
+	return false
+}
+
+// The layout of levelCache: the lowest 32 bits are the effective level, the
+// next bit is the disabled flag, and the highest 31 bits are the generation.
+const (
+	levelCacheDisabledBit     = 1 << 32
+	levelCacheGenerationShift = 33
+)
+
+// packLevelCache packs the arguments with the layout of levelCache.
+func packLevelCache(generation uint32, level int, disabled bool) uint64 {
+	var cache = uint64(generation)<<levelCacheGenerationShift | uint64(uint32(level))
+	if disabled {
+		cache |= levelCacheDisabledBit
+	}
+	return cache
+}
+
+// currentGeneration returns the lowest 31 bits of generation, which
+// are stored in levelCache.
+func currentGeneration() uint32 {
+	return atomic.LoadUint32(&generation) & (1<<31 - 1)
+}
+
+// invalidateCaches invalidates the cached effective levels and Handler chains
+// of all Loggers. It must be called after changing anything which affects the
+// effective level, the disabled flag, or the Handler chain of a Logger.
+func invalidateCaches() {
+	if currentGeneration() == 1<<31-1 {
+		// Skip the zero generation, which matches the zero value of caches.
+		atomic.AddUint32(&generation, 1)
+	}
+	atomic.AddUint32(&generation, 1)
+}
+
+// lazyString converts a function returning string to a lazy value.
+func lazyString(f func() string) func() any {
+	xycond.AssertNotNil(f)
+	return func() any { return f() }
+}
+
+// replaceHandler replaces the old Handler with h in this Logger and all its
+// descendants. The old Handler is removed if h is nil. It must be called while
+// holding the lock of Registry.
+func (lg *Logger) replaceHandler(old, h *Handler) {
+	lg.lock.Lock()
+	var handlers []*Handler
+	for i := range lg.handlers {
+		switch {
+		case lg.handlers[i] != old:
+			handlers = append(handlers, lg.handlers[i])
+		case h != nil:
+			handlers = append(handlers, h)
+		}
+	}
+	lg.handlers = handlers
+	lg.lock.Unlock()
+
 	for _, child := range lg.Children() {
-		child.clearCache()
+		child.replaceHandler(old, h)
 	}
 }
 
+// touchInterval is the precision of lastUsed in nanoseconds. Logging only
+// updates lastUsed if it is older than this (or newer than the given time if
+// the clock was set back), so most records don't write to the shared Logger.
+const touchInterval = int64(time.Second)
+
+// touch marks this Logger as used at the given time.
+func (lg *Logger) touch(t time.Time) {
+	var now = t.UnixNano()
+	var last = atomic.LoadInt64(&lg.lastUsed)
+	if now-last >= touchInterval || now < last {
+		atomic.StoreInt64(&lg.lastUsed, now)
+	}
+}
+
+// prune removes idle leaf descendants which were used before the deadline in
+// nanoseconds, then returns the number of removed loggers. It must be called
+// while holding the lock of Registry.
+func (lg *Logger) prune(deadline int64) int {
+	var count = 0
+	for key, child := range lg.children {
+		count += child.prune(deadline)
+		if child.isIdleLeaf(deadline) {
+			lg.lock.WLockFunc(func() { delete(lg.children, key) })
+			count++
+		}
+	}
+	return count
+}
+
+// isIdleLeaf checks if this Logger has no child, no configuration, and was
+// used before the deadline in nanoseconds.
+func (lg *Logger) isIdleLeaf(deadline int64) bool {
+	lg.lock.RLock()
+	defer lg.lock.RUnlock()
+
+	return len(lg.children) == 0 && len(lg.handlers) == 0 &&
+		len(lg.f.Filters()) == 0 && len(lg.fields) == 0 &&
+		lg.level == NOTSET && lg.override == nil && lg.propagate &&
+		!lg.disabled && atomic.LoadInt64(&lg.lastUsed) < deadline
+}
+
 // newLogger creates a Logger with a name and parent. The fullname of logger
 // will be concatenated by the parent's fullname. This logger will not be
 // automatically added to logger hierarchy. The returned logger has no child,
 // no handler, and NOTSET level.
-func newLogger(name string, parent *Logger) *Logger {
-	var current = parent
-	if current != nil && current != rootLogger {
-		name = current.Name() + "." + name
-	}
-
-	return &Logger{
-		f:        &filterer{},
-		name:     name,
-		children: make(map[string]*Logger),
-		parent:   parent,
-		level:    NOTSET,
-		handlers: nil,
-		lock:     &xylock.RWLock{},
-		cache:    make(map[int]bool),
+func newLogger(name string, parent *Logger, r *Registry) *Logger {
+	if parent != nil && parent != r.root {
+		name = parent.Name() + "." + name
 	}
+
+	return &Logger{loggerCore: &loggerCore{
+		f:         &filterer{},
+		registry:  r,
+		name:      name,
+		children:  make(map[string]*Logger),
+		parent:    parent,
+		level:     NOTSET,
+		handlers:  nil,
+		lock:      &xylock.RWLock{},
+		propagate: true,
+	}}
 }
@@ -21,10 +21,14 @@
 package xylog_test
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
 	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/encoding"
 	"github.com/xybor-x/xylog/test"
 )
 
@@ -44,15 +48,17 @@ func TestLoggerLogMethods(t *testing.T) {
 			methodf func(string, ...any)
 			method  func(string)
 		}{
+			{logger.Tracef, logger.Trace},
 			{logger.Debugf, logger.Debug},
 			{logger.Infof, logger.Info},
+			{logger.Noticef, logger.Notice},
 			{logger.Warnf, logger.Warn},
 			{logger.Warningf, logger.Warning},
 			{logger.Errorf, logger.Error},
 			{logger.Criticalf, logger.Critical},
 		}
 
-		logger.SetLevel(xylog.DEBUG)
+		logger.SetLevel(xylog.TRACE)
 		for i := range tests {
 			w.Reset()
 			var msg = test.GetRandomMessage()
@@ -180,7 +186,7 @@ func TestLoggerFindCaller(t *testing.T) {
 
 		logger.Error("foo")
 
-		xycond.ExpectIn("lineno=181", w.Captured).Test(t)
+		xycond.ExpectIn("lineno=187", w.Captured).Test(t)
 		xycond.ExpectIn(
 			"module=github.com/xybor-x/xylog_test", w.Captured).Test(t)
 		xycond.ExpectIn(
@@ -203,3 +209,479 @@ func TestLoggerFlushParent(t *testing.T) {
 
 	xycond.ExpectIn("foo", writer.Captured).Test(t)
 }
+
+func TestLoggerSequence(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var handler = logger.Handlers()[0]
+		handler.SetEncoding(encoding.NewJSONEncoding())
+		handler.AddMacro("sequence", "sequence")
+
+		var records []struct{ Sequence uint64 }
+		for i := 0; i < 3; i++ {
+			w.Reset()
+			logger.Error("foo")
+			var r struct{ Sequence uint64 }
+			xycond.ExpectNil(json.Unmarshal([]byte(w.Captured), &r)).Test(t)
+			records = append(records, r)
+		}
+
+		xycond.ExpectLessThan(records[0].Sequence, records[1].Sequence).Test(t)
+		xycond.ExpectLessThan(records[1].Sequence, records[2].Sequence).Test(t)
+	})
+}
+
+func TestLoggerFindGoroutine(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var handler = logger.Handlers()[0]
+		handler.AddMacro("goroutine", "goroutine")
+
+		logger.Error("foo")
+		xycond.ExpectIn("goroutine=-1", w.Captured).Test(t)
+
+		xylog.SetFindGoroutine(true)
+		defer xylog.SetFindGoroutine(false)
+		w.Reset()
+		logger.Error("foo")
+		xycond.ExpectNotIn("goroutine=-1", w.Captured).Test(t)
+		xycond.ExpectIn("goroutine=", w.Captured).Test(t)
+	})
+}
+
+func TestLoggerWith(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.AddField("service", "foo")
+		var derived = logger.With("request_id", 1, "user", "bar")
+		var nested = derived.With("attempt", 2)
+
+		nested.Error("nested")
+		xycond.ExpectEqual(w.Captured, "messsage=nested service=foo "+
+			"request_id=1 user=bar attempt=2\n").Test(t)
+
+		w.Reset()
+		derived.Event("derived").Error()
+		xycond.ExpectEqual(w.Captured, "event=derived service=foo "+
+			"request_id=1 user=bar\n").Test(t)
+
+		w.Reset()
+		logger.Error("origin")
+		xycond.ExpectEqual(w.Captured, "messsage=origin service=foo\n").Test(t)
+
+		xycond.ExpectEqual(derived.Name(), logger.Name()).Test(t)
+		logger.SetLevel(xylog.CRITICAL)
+		w.Reset()
+		derived.Error("blocked")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+	})
+}
+
+func TestLoggerWithInvalidPairs(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.With("foo").Error("odd")
+		xycond.ExpectEqual(w.Captured, "messsage=odd !BADKEY=foo\n").Test(t)
+
+		w.Reset()
+		logger.With(1, "foo", "user", "bar").Error("key")
+		xycond.ExpectEqual(w.Captured,
+			"messsage=key !BADKEY=1 foo=user !BADKEY=bar\n").Test(t)
+	})
+}
+
+func TestLoggerSugaredMethods(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var tests = []func(string, ...any){
+			logger.Tracew,
+			logger.Debugw,
+			logger.Infow,
+			logger.Noticew,
+			logger.Warnw,
+			logger.Warningw,
+			logger.Errorw,
+			logger.Criticalw,
+		}
+
+		logger.SetLevel(xylog.TRACE)
+		for i := range tests {
+			w.Reset()
+			tests[i]("foo", "user", "bar", "attempt", 3)
+			xycond.ExpectEqual(w.Captured,
+				"messsage=foo user=bar attempt=3\n").Test(t)
+		}
+
+		w.Reset()
+		logger.Logw(xylog.DEBUG, "foo", "user", "bar")
+		xycond.ExpectEqual(w.Captured, "messsage=foo user=bar\n").Test(t)
+
+		w.Reset()
+		logger.Infow("foo", "user", "bar", 3, "attempt")
+		xycond.ExpectEqual(w.Captured,
+			"messsage=foo user=bar !BADKEY=3 !BADKEY=attempt\n").Test(t)
+
+		logger.SetLevel(xylog.NOTLOG)
+		w.Reset()
+		logger.Criticalw("foo", "user", "bar")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+	})
+}
+
+func TestLoggerEnabled(t *testing.T) {
+	var logger = xylog.GetLogger(t.Name())
+	logger.SetLevel(xylog.INFO)
+	xycond.ExpectFalse(logger.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(logger.Enabled(xylog.INFO)).Test(t)
+	xycond.ExpectTrue(logger.Enabled(xylog.ERROR)).Test(t)
+}
+
+func TestLoggerLazyMethods(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var tests = []func(func() string){
+			logger.TraceFn,
+			logger.DebugFn,
+			logger.InfoFn,
+			logger.NoticeFn,
+			logger.WarnFn,
+			logger.WarningFn,
+			logger.ErrorFn,
+			logger.CriticalFn,
+		}
+
+		logger.SetLevel(xylog.TRACE)
+		for i := range tests {
+			w.Reset()
+			tests[i](func() string { return "foo" })
+			xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+		}
+
+		w.Reset()
+		logger.LogFn(xylog.DEBUG, func() string { return "foo" })
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestLoggerLazyNotEvaluated(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var handler = registry.GetHandler("")
+	handler.AddEmitter(xylog.NewStreamEmitter(&test.MockWriter{}))
+	var logger = registry.GetLogger(t.Name())
+	logger.AddHandler(handler)
+
+	var called = 0
+	var lazy = func() string { called++; return "foo" }
+
+	logger.SetLevel(xylog.ERROR)
+	logger.DebugFn(lazy)
+	xycond.ExpectZero(called).Test(t)
+
+	handler.SetLevel(xylog.CRITICAL)
+	logger.ErrorFn(lazy)
+	xycond.ExpectZero(called).Test(t)
+
+	handler.SetLevel(xylog.NOTSET)
+	var filter = &test.LoggerNameFilter{Name: "other"}
+	handler.AddFilter(filter)
+	logger.ErrorFn(lazy)
+	xycond.ExpectZero(called).Test(t)
+
+	handler.RemoveFilter(filter)
+	logger.ErrorFn(lazy)
+	xycond.ExpectEqual(called, 1).Test(t)
+}
+
+func TestLoggerLazyFieldEvaluatedOnce(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var writer = &test.MockWriter{}
+	var logger = registry.GetLogger(t.Name())
+	var child = registry.GetLogger(t.Name() + ".child")
+	for _, lg := range []*xylog.Logger{logger, child} {
+		var handler = registry.GetHandler("")
+		handler.AddEmitter(xylog.NewStreamEmitter(writer))
+		lg.AddHandler(handler)
+	}
+
+	var called = 0
+	child.Event("foo").Field("lazy", func() any { called++; return 1 }).
+		Error()
+	xycond.ExpectEqual(called, 1).Test(t)
+	xycond.ExpectEqual(writer.Captured, "event=foo lazy=1\nevent=foo lazy=1\n").
+		Test(t)
+}
+
+func TestLoggerPropagate(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var writer = &test.MockWriter{}
+		var handler = xylog.GetHandler("")
+		handler.AddEmitter(xylog.NewStreamEmitter(writer))
+
+		var child = xylog.GetLogger(t.Name() + ".child")
+		var grandchild = xylog.GetLogger(t.Name() + ".child.grandchild")
+		child.AddHandler(handler)
+		xycond.ExpectTrue(child.Propagate()).Test(t)
+
+		grandchild.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+		xycond.ExpectEqual(writer.Captured, "messsage=foo\n").Test(t)
+
+		w.Reset()
+		writer.Reset()
+		child.SetPropagate(false)
+		xycond.ExpectFalse(child.Propagate()).Test(t)
+		grandchild.Error("foo")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+		xycond.ExpectEqual(writer.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestLoggerSetDisabled(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var child = xylog.GetLogger(t.Name() + ".child")
+		logger.SetDisabled(true)
+		xycond.ExpectTrue(logger.Disabled()).Test(t)
+		xycond.ExpectFalse(logger.Enabled(xylog.CRITICAL)).Test(t)
+
+		logger.Critical("foo")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+
+		child.Critical("bar")
+		xycond.ExpectEqual(w.Captured, "messsage=bar\n").Test(t)
+
+		w.Reset()
+		logger.SetDisabled(false)
+		logger.Critical("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestRemoveLogger(t *testing.T) {
+	var logger = xylog.GetLogger(t.Name() + ".a")
+	var child = xylog.GetLogger(t.Name() + ".a.b")
+	var parent = xylog.GetLogger(t.Name())
+	parent.SetLevel(xylog.ERROR)
+	xycond.ExpectFalse(child.Enabled(xylog.INFO)).Test(t)
+
+	xylog.RemoveLogger(t.Name() + ".a")
+	xylog.RemoveLogger(t.Name() + ".not.exist")
+	xycond.ExpectEmpty(parent.Children()).Test(t)
+	xycond.ExpectEqual(logger.Parent(), parent).Test(t)
+	xycond.ExpectEqual(child.Parent(), logger).Test(t)
+	xycond.ExpectFalse(child.Enabled(xylog.INFO)).Test(t)
+
+	var newLogger = xylog.GetLogger(t.Name() + ".a.b")
+	xycond.ExpectNotEqual(newLogger, child).Test(t)
+	xycond.ExpectEqual(newLogger.Parent().Parent(), parent).Test(t)
+	xycond.ExpectFalse(newLogger.Enabled(xylog.INFO)).Test(t)
+
+	xycond.ExpectPanic(xyerror.AssertionError, func() {
+		xylog.RemoveLogger("")
+	}).Test(t)
+}
+
+func TestPruneLoggers(t *testing.T) {
+	test.WithFakeClock(time.Now(), func(c *test.FakeClock) {
+		var parent = xylog.GetLogger(t.Name())
+		parent.SetLevel(xylog.INFO)
+		var idle = xylog.GetLogger(t.Name() + ".idle.leaf")
+		var configured = xylog.GetLogger(t.Name() + ".configured")
+		configured.SetLevel(xylog.DEBUG)
+		var used = xylog.GetLogger(t.Name() + ".used")
+
+		c.Advance(time.Minute)
+		used.Info("foo")
+		c.Advance(time.Minute)
+
+		xycond.ExpectNotLessThan(xylog.PruneLoggers(90*time.Second), 2).Test(t)
+		xycond.ExpectEqual(idle.Parent().Parent(), parent).Test(t)
+		xycond.ExpectEqual(configured.Parent(), parent).Test(t)
+		xycond.ExpectEqual(used.Parent(), parent).Test(t)
+		xycond.ExpectEqual(len(parent.Children()), 2).Test(t)
+		xycond.ExpectNotEqual(xylog.GetLogger(t.Name()+".idle.leaf"), idle).
+			Test(t)
+	})
+}
+
+func TestSetAutoPrune(t *testing.T) {
+	var parent = xylog.GetLogger(t.Name())
+	parent.SetLevel(xylog.INFO)
+	xylog.GetLogger(t.Name() + ".idle")
+
+	xylog.SetAutoPrune(time.Millisecond)
+	defer xylog.SetAutoPrune(0)
+
+	var deadline = time.Now().Add(time.Second)
+	for len(parent.Children()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	xycond.ExpectEmpty(parent.Children()).Test(t)
+}
+
+func TestLoggerLevelCacheInvalidation(t *testing.T) {
+	var parent = xylog.GetLogger(t.Name())
+	var child = xylog.GetLogger(t.Name() + ".child")
+	parent.SetLevel(xylog.INFO)
+	xycond.ExpectFalse(child.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(child.Enabled(xylog.INFO)).Test(t)
+
+	parent.SetLevel(xylog.DEBUG)
+	xycond.ExpectTrue(child.Enabled(xylog.DEBUG)).Test(t)
+
+	child.SetDisabled(true)
+	xycond.ExpectFalse(child.Enabled(xylog.CRITICAL)).Test(t)
+	child.SetDisabled(false)
+	xycond.ExpectTrue(child.Enabled(xylog.DEBUG)).Test(t)
+
+	parent.SetLevel(xylog.ERROR)
+	xycond.ExpectFalse(child.Enabled(xylog.DEBUG)).Test(t)
+	xylog.RemoveLogger(t.Name() + ".child")
+	parent.SetLevel(xylog.DEBUG)
+	xycond.ExpectTrue(child.Enabled(xylog.DEBUG)).Test(t)
+}
+
+func TestLoggerLevelCacheConcurrently(t *testing.T) {
+	var parent = xylog.GetLogger(t.Name())
+	var child = xylog.GetLogger(t.Name() + ".child")
+	parent.SetLevel(xylog.ERROR)
+
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			child.Enabled(xylog.INFO)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		parent.SetLevel(xylog.DEBUG)
+		parent.SetLevel(xylog.ERROR)
+	}
+	<-done
+
+	xycond.ExpectFalse(child.Enabled(xylog.INFO)).Test(t)
+	parent.SetLevel(xylog.INFO)
+	xycond.ExpectTrue(child.Enabled(xylog.INFO)).Test(t)
+}
+
+func TestLoggerHandlerDeduplicated(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var child = xylog.GetLogger(t.Name() + ".child")
+		child.AddHandler(logger.Handlers()[0])
+		child.AddHandler(logger.Handlers()[0])
+
+		child.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestLoggerHandlerChainInvalidation(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var writer = &test.MockWriter{}
+		var handler = xylog.GetHandler("")
+		handler.AddEmitter(xylog.NewStreamEmitter(writer))
+
+		var child = xylog.GetLogger(t.Name() + ".child")
+		child.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+
+		w.Reset()
+		child.AddHandler(handler)
+		child.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+		xycond.ExpectEqual(writer.Captured, "messsage=foo\n").Test(t)
+
+		w.Reset()
+		writer.Reset()
+		child.SetPropagate(false)
+		child.Error("foo")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+		xycond.ExpectEqual(writer.Captured, "messsage=foo\n").Test(t)
+
+		writer.Reset()
+		child.RemoveHandler(handler)
+		child.Error("foo")
+		xycond.ExpectEmpty(writer.Captured).Test(t)
+
+		child.SetPropagate(true)
+		logger.RemoveAllHandlers()
+		child.Error("foo")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+	})
+}
+
+func TestLoggerHandlersCopy(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var lg = xylog.GetLogger(t.Name())
+	var handler = xylog.GetHandler("")
+	lg.AddHandler(handler)
+
+	var handlers = lg.Handlers()
+	handlers[0] = nil
+	xycond.ExpectEqual(lg.Handlers()[0], handler).Test(t)
+}
+
+func TestLoggerWithPreencoded(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var jsonHandler = xylog.GetHandler("")
+		jsonHandler.SetEncoding(encoding.NewJSONEncoding())
+		jsonHandler.AddEmitter(xylog.NewStreamEmitter(w))
+		logger.AddHandler(jsonHandler)
+
+		var derived = logger.With("request_id", 1, "user", "bar")
+		for i := 0; i < 2; i++ {
+			w.Reset()
+			derived.Error("foo")
+			xycond.ExpectEqual(w.Captured, "messsage=foo request_id=1 user=bar\n"+
+				`{"messsage":"foo","request_id":1,"user":"bar"}`+"\n").Test(t)
+		}
+	})
+}
+
+func TestLoggerWithLazyValue(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var count = 0
+		var derived = logger.With(
+			"attempt", func() any { count++; return count }, "user", "bar")
+
+		derived.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo user=bar attempt=1\n").
+			Test(t)
+
+		w.Reset()
+		derived.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo user=bar attempt=2\n").
+			Test(t)
+	})
+}
+
+type fieldsFilter struct {
+	fields int
+}
+
+func (f *fieldsFilter) Filter(r xylog.LogRecord) bool {
+	f.fields = len(r.Fields)
+	return true
+}
+
+func TestLoggerWithFieldsInRecord(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var filter = &fieldsFilter{}
+		logger.AddFilter(filter)
+		logger.With("request_id", 1, "user", "bar").Error("foo")
+		xycond.ExpectEqual(filter.fields, 3).Test(t)
+	})
+}
+
+func TestLoggerPanicw(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		xycond.ExpectPanic("foo", func() {
+			logger.Panicw("foo", "user", "bar")
+		}).Test(t)
+		xycond.ExpectEqual(w.Captured, "messsage=foo user=bar\n").Test(t)
+	})
+}
+
+func TestRemovedLoggerKeepsAncestorHandlers(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var child = xylog.GetLogger(t.Name() + ".child")
+		xylog.RemoveLogger(t.Name() + ".child")
+		child.Error("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
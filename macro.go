@@ -0,0 +1,124 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"strings"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+)
+
+// MacroFunc computes the value of a macro from a LogRecord. It is called every
+// time a Handler using the macro formats a record, so it should be fast and
+// safe for concurrent use.
+type MacroFunc func(record LogRecord) any
+
+// macro is a compiled macro which is associated with a key in the logging
+// message. The spec is kept to compile the macro again when the time format of
+// Handler changes.
+type macro struct {
+	key  string
+	spec string
+	get  MacroFunc
+}
+
+// macroRegistry maps names of macros to their MacroFuncs. It contains built-in
+// macros by default, the others are added by RegisterMacro. The asctime macro
+// is not here because it depends on the time format of Handler.
+var macroRegistry = map[string]MacroFunc{
+	"created":         func(r LogRecord) any { return r.Created },
+	"createdNanos":    func(r LogRecord) any { return r.Time.UnixNano() },
+	"filename":        func(r LogRecord) any { return r.FileName },
+	"funcname":        func(r LogRecord) any { return r.FuncName },
+	"goroutine":       func(r LogRecord) any { return r.GoroutineID },
+	"levelname":       func(r LogRecord) any { return r.LevelName },
+	"levelno":         func(r LogRecord) any { return r.LevelNo },
+	"lineno":          func(r LogRecord) any { return r.LineNo },
+	"module":          func(r LogRecord) any { return r.Module },
+	"msecs":           func(r LogRecord) any { return r.Msecs },
+	"name":            func(r LogRecord) any { return r.Name },
+	"otelSeverity":    func(r LogRecord) any { return GetSeverity(r.LevelNo).OTel },
+	"pathname":        func(r LogRecord) any { return r.PathName },
+	"process":         func(r LogRecord) any { return r.Process },
+	"relativeCreated": func(r LogRecord) any { return r.RelativeCreated },
+	"sequence":        func(r LogRecord) any { return r.Sequence },
+	"span_id":         traceMacro(TraceContext.spanID),
+	"syslogSeverity":  func(r LogRecord) any { return GetSeverity(r.LevelNo).Syslog },
+	"trace_flags":     traceMacro(TraceContext.traceFlags),
+	"trace_id":        traceMacro(TraceContext.traceID),
+}
+
+// RegisterMacro associates a macro name with a MacroFunc. After that, the name
+// can be used in Handler.AddMacro and SimpleConfig.AddMacro like built-in
+// macros. It panics if the name has already been registered or contains the
+// modifier separator "|".
+func RegisterMacro(name string, fn MacroFunc) {
+	xycond.AssertNotEmpty(name)
+	xycond.AssertFalse(strings.Contains(name, "|"))
+	xycond.AssertNotNil(fn)
+
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	xycond.AssertNotEqual(name, "asctime")
+	xycond.AssertNotIn(name, macroRegistry)
+	macroRegistry[name] = fn
+}
+
+// compileMacro resolves a macro in form of "name|modifier|modifier:arg" to its
+// MacroFunc. The asctime macro is formatted with the given timeFormat, its time
+// modifiers are applied to the timeFormat instead of the value. It returns an
+// error if the macro or any modifier is invalid.
+func compileMacro(spec string, tf timeFormat) (MacroFunc, error) {
+	var parts = strings.Split(spec, "|")
+	var name, modifiers = parts[0], parts[1:]
+
+	var get MacroFunc
+	if name == "asctime" {
+		for len(modifiers) > 0 && isTimeModifier(modifiers[0]) {
+			tf.location = timeModifiers[modifiers[0]]
+			modifiers = modifiers[1:]
+		}
+		get = tf.macro()
+	} else {
+		var ok bool
+		globalLock.RLock()
+		get, ok = macroRegistry[name]
+		globalLock.RUnlock()
+
+		if !ok {
+			return nil, xyerror.ValueError.Newf("not found macro %s", name)
+		}
+	}
+
+	for i := range modifiers {
+		var err error
+		get, err = applyModifier(get, modifiers[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return get, nil
+}
+
+func makeMacro(key, spec string, get MacroFunc) macro {
+	return macro{key: key, spec: spec, get: get}
+}
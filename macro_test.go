@@ -0,0 +1,65 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"testing"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func init() {
+	xylog.RegisterMacro("test_version", func(xylog.LogRecord) any {
+		return "v1.2.3"
+	})
+	xylog.RegisterMacro("test_level", func(r xylog.LogRecord) any {
+		return r.LevelNo * 2
+	})
+}
+
+func TestRegisterMacro(t *testing.T) {
+	test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+		xycond.ExpectNil(h.AddMacro("version", "test_version")).Test(t)
+		xycond.ExpectNil(h.AddMacro("level", "test_level")).Test(t)
+		h.Handle(xylog.LogRecord{LevelNo: xylog.INFO})
+
+		xycond.ExpectEqual("version=v1.2.3 level=40\n", w.Captured).Test(t)
+	})
+}
+
+func TestRegisterMacroDuplicated(t *testing.T) {
+	xycond.ExpectPanic(xyerror.AssertionError, func() {
+		xylog.RegisterMacro("levelname", func(xylog.LogRecord) any { return "" })
+	}).Test(t)
+}
+
+func TestRegisterMacroSimpleConfig(t *testing.T) {
+	var writer = &test.MockWriter{}
+	var cfg = &xylog.SimpleConfig{Name: t.Name(), Writer: writer}
+	var logger, err = cfg.AddMacro("version", "test_version").Apply()
+	xycond.ExpectNil(err).Test(t)
+
+	logger.Error("foo")
+	xycond.ExpectEqual("version=v1.2.3 messsage=foo\n", writer.Captured).Test(t)
+}
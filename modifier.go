@@ -0,0 +1,187 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xybor-x/xyerror"
+)
+
+// modifier transforms the value of a macro.
+type modifier func(v any) any
+
+// modifierBuilders maps names of modifiers to functions building them from the
+// argument following the colon.
+var modifierBuilders = map[string]func(arg string) (modifier, error){
+	"lower":    noArg(func(v any) any { return strings.ToLower(toString(v)) }),
+	"upper":    noArg(func(v any) any { return strings.ToUpper(toString(v)) }),
+	"pad":      withWidth(padRight),
+	"lpad":     withWidth(padLeft),
+	"trunc":    withWidth(truncate),
+	"shorten":  withWidth(shorten),
+	"relative": buildRelative,
+}
+
+// timeModifiers maps names of time modifiers to their timezones. They are only
+// valid when directly following the asctime macro.
+var timeModifiers = map[string]*time.Location{
+	"utc":   time.UTC,
+	"local": time.Local,
+}
+
+// isTimeModifier checks if the modifier is a time modifier.
+func isTimeModifier(spec string) bool {
+	var _, ok = timeModifiers[spec]
+	return ok
+}
+
+// applyModifier parses a modifier in form of "name" or "name:arg", then wraps
+// the MacroFunc with it.
+func applyModifier(get MacroFunc, spec string) (MacroFunc, error) {
+	var name, arg, _ = strings.Cut(spec, ":")
+
+	if _, ok := timeModifiers[name]; ok {
+		return nil, xyerror.ValueError.Newf(
+			"modifier %s must directly follow asctime", name)
+	}
+
+	var build, ok = modifierBuilders[name]
+	if !ok {
+		return nil, xyerror.ValueError.Newf("not found modifier %s", name)
+	}
+
+	var mod, err = build(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r LogRecord) any { return mod(get(r)) }, nil
+}
+
+// noArg creates a modifier builder which accepts no argument.
+func noArg(mod modifier) func(string) (modifier, error) {
+	return func(arg string) (modifier, error) {
+		if arg != "" {
+			return nil, xyerror.ValueError.Newf("unexpected argument %s", arg)
+		}
+		return mod, nil
+	}
+}
+
+// withWidth creates a modifier builder whose argument is a non-negative width.
+func withWidth(f func(s string, n int) string) func(string) (modifier, error) {
+	return func(arg string) (modifier, error) {
+		var n, err = strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return nil, xyerror.ValueError.Newf("invalid width %q", arg)
+		}
+		return func(v any) any { return f(toString(v), n) }, nil
+	}
+}
+
+// buildRelative creates a modifier converting an absolute path to the one
+// relative to the current working directory.
+func buildRelative(arg string) (modifier, error) {
+	if arg != "" {
+		return nil, xyerror.ValueError.Newf("unexpected argument %s", arg)
+	}
+
+	var wd, err = os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v any) any {
+		var path = toString(v)
+		if rel, err := filepath.Rel(wd, path); err == nil {
+			return rel
+		}
+		return path
+	}, nil
+}
+
+// padRight appends spaces to s until it has n characters.
+func padRight(s string, n int) string {
+	if c := utf8.RuneCountInString(s); c < n {
+		return s + strings.Repeat(" ", n-c)
+	}
+	return s
+}
+
+// padLeft prepends spaces to s until it has n characters.
+func padLeft(s string, n int) string {
+	if c := utf8.RuneCountInString(s); c < n {
+		return strings.Repeat(" ", n-c) + s
+	}
+	return s
+}
+
+// truncate keeps at most n first characters of s.
+func truncate(s string, n int) string {
+	var i = 0
+	for j := range s {
+		if i == n {
+			return s[:j]
+		}
+		i++
+	}
+	return s
+}
+
+// shorten abbreviates a dot-separated name to at most n characters if
+// possible. From left to right, every part of the name is reduced to its first
+// character until the name is short enough. The last part is never abbreviated.
+func shorten(s string, n int) string {
+	var length = utf8.RuneCountInString(s)
+	if length <= n {
+		return s
+	}
+
+	var parts = strings.Split(s, ".")
+	for i := 0; i < len(parts)-1 && length > n; i++ {
+		if _, size := utf8.DecodeRuneInString(parts[i]); size < len(parts[i]) {
+			length -= utf8.RuneCountInString(parts[i]) - 1
+			parts[i] = parts[i][:size]
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// toString converts a macro value to string.
+func toString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func TestModifiers(t *testing.T) {
+	var wd, _ = os.Getwd()
+	var record = xylog.LogRecord{
+		FuncName:  "handleRequest",
+		LevelName: "WARNING",
+		LineNo:    42,
+		Name:      "mainpackage.sub.sample.Bar",
+		PathName:  filepath.Join(wd, "foo", "bar.go"),
+	}
+
+	var tests = []struct {
+		macro    string
+		expected string
+	}{
+		{"levelname|lower", "warning"},
+		{"funcname|upper", "HANDLEREQUEST"},
+		{"levelname|pad:9", `"WARNING  "`},
+		{"lineno|lpad:4", `"  42"`},
+		{"funcname|trunc:6", "handle"},
+		{"funcname|trunc:30", "handleRequest"},
+		{"name|shorten:20", "m.sub.sample.Bar"},
+		{"name|shorten:15", "m.s.sample.Bar"},
+		{"name|shorten:0", "m.s.s.Bar"},
+		{"name|shorten:30", "mainpackage.sub.sample.Bar"},
+		{"pathname|relative", filepath.Join("foo", "bar.go")},
+		{"levelname|lower|trunc:4", "warn"},
+	}
+
+	for i := range tests {
+		t.Run(tests[i].macro, func(t *testing.T) {
+			test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+				xycond.ExpectNil(h.AddMacro("m", tests[i].macro)).Test(t)
+				h.Handle(record)
+				xycond.ExpectEqual(w.Captured, "m="+tests[i].expected+"\n").
+					Test(t)
+			})
+		})
+	}
+}
+
+func TestModifierShortenUnicode(t *testing.T) {
+	var tests = []struct {
+		n        string
+		expected string
+	}{
+		{"15", "độngcơ.xửlý.Bar"},
+		{"12", "đ.xửlý.Bar"},
+		{"9", "đ.x.Bar"},
+	}
+
+	for i := range tests {
+		test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+			xycond.ExpectNil(h.AddMacro("m", "name|shorten:"+tests[i].n)).
+				Test(t)
+			h.Handle(xylog.LogRecord{Name: "độngcơ.xửlý.Bar"})
+			xycond.ExpectEqual(w.Captured, "m="+tests[i].expected+"\n").
+				Test(t)
+		})
+	}
+}
+
+func TestModifierInvalid(t *testing.T) {
+	var macros = []string{
+		"levelname|unknown",
+		"levelname|lower:1",
+		"levelname|trunc",
+		"levelname|trunc:-1",
+		"levelname|pad:a",
+		"levelname|utc",
+		"asctime|lower|utc",
+		"asctime|utc:1",
+	}
+
+	var handler = xylog.GetHandler("")
+	for i := range macros {
+		var err = handler.AddMacro("m", macros[i])
+		xycond.ExpectError(err, xyerror.ValueError).Test(t)
+	}
+}
+
+func TestModifierUTC(t *testing.T) {
+	test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+		var loc = time.FixedZone("UTC+7", 7*60*60)
+		xycond.ExpectNil(h.AddMacro("time", "asctime|utc")).Test(t)
+
+		h.Handle(xylog.LogRecord{Time: test.FullRecord.Time.In(loc)})
+		xycond.ExpectEqual(w.Captured,
+			"time=2023-01-02T03:04:05.000000006Z\n").Test(t)
+	})
+}
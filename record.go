@@ -21,11 +21,14 @@
 package xylog
 
 import (
+	"context"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"sync/atomic"
 	"time"
 
-	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog/encoding"
 )
 
 // A LogRecord instance represents an event being logged.
@@ -35,14 +38,16 @@ import (
 // passed in is Message. The record also includes information as when the record
 // was created or the source line where the logging call was made.
 type LogRecord struct {
-	// Textual time when the LogRecord was created.
-	Asctime string
-
-	// Time when the LogRecord was created (time.Now().Unix() return value).
+	// Time when the LogRecord was created (Clock.Now().Unix() return value).
 	Created int64
 
+	// Context which the LogRecord was logged with. It is nil if the record was
+	// logged without context.
+	Context context.Context
+
 	// This a not a macro. Fields are always added to the logging message
-	// without calling AddMacro.
+	// without calling AddMacro. A field whose value is a func() any is lazy, it
+	// is only evaluated when a Handler is going to log the record.
 	Fields []field
 
 	// Filename is the portion of pathname.
@@ -51,6 +56,9 @@ type LogRecord struct {
 	// Funcname is the name of function which logged the record.
 	FuncName string
 
+	// ID of the goroutine which logged the record.
+	GoroutineID int64
+
 	// Text logging level for the message ("DEBUG", "INFO", "WARNING", "ERROR",
 	// "CRITICAL").
 	LevelName string
@@ -80,47 +88,48 @@ type LogRecord struct {
 	// Time in milliseconds when the LogRecord was created, relative to the time
 	// the logging module was loaded (typically at application startup time).
 	RelativeCreated int64
+
+	// Process-wide sequence number of the LogRecord. It increases
+	// monotonically, so it can order records created at the same time.
+	Sequence uint64
+
+	// Time when the LogRecord was created. Handlers format it to asctime macro
+	// only if they need.
+	Time time.Time
+
+	// extra caches the encoded fields of a Logger derived by With. They are
+	// extraLen fields in Fields, starting at the extraAt index. It is nil if
+	// the record is not logged by a derived Logger.
+	extra    *encoding.Fields
+	extraAt  int
+	extraLen int
+
+	// contextLen is the number of fields extracted from the context, they are
+	// at the end of Fields.
+	contextLen int
 }
 
-func (r LogRecord) getValue(name string) (any, error) {
-	switch name {
-	case "asctime":
-		return r.Asctime, nil
-	case "created":
-		return r.Created, nil
-	case "filename":
-		return r.FileName, nil
-	case "funcname":
-		return r.FuncName, nil
-	case "levelname":
-		return r.LevelName, nil
-	case "levelno":
-		return r.LevelNo, nil
-	case "lineno":
-		return r.LineNo, nil
-	case "module":
-		return r.Module, nil
-	case "msecs":
-		return r.Msecs, nil
-	case "name":
-		return r.Name, nil
-	case "pathname":
-		return r.PathName, nil
-	case "process":
-		return r.Process, nil
-	case "relativeCreated":
-		return r.RelativeCreated, nil
-	default:
-		return nil, xyerror.ValueError.Newf("not found attribute %s", name)
-	}
+// Asctime returns the textual time when the LogRecord was created, formatted
+// with time.RFC3339Nano.
+//
+// Deprecated: Asctime was a field formatted with the global time layout for
+// every record. Use Time instead, Handlers format it with their own layout.
+func (r LogRecord) Asctime() string {
+	return r.Time.Format(time.RFC3339Nano)
 }
 
-// makeRecord creates specialized LogRecords.
-func makeRecord(name string, level int, fields ...field) LogRecord {
-	var created = time.Now()
+// makeRecord creates specialized LogRecords with settings of the Registry.
+func (r *Registry) makeRecord(name string, level int, fields ...field) LogRecord {
+	var s = r.loadSettings()
+	var clock, startTime = s.clock, s.startTime
+	var findCaller, findGoroutine = s.findCaller, s.findGoroutine
+	var skipCall = s.skipCall
+
+	var created = clock.Now()
 	var pc uintptr
 	var lineno int
 	var module, pathname, funcname = "unknown", "unknown", "unknown"
+	var goroutineID int64 = -1
 	if findCaller {
 		var ok bool
 		pc, pathname, lineno, ok = runtime.Caller(skipCall)
@@ -131,13 +140,16 @@ func makeRecord(name string, level int, fields ...field) LogRecord {
 			module, funcname = extractFromPC(pc)
 		}
 	}
+	if findGoroutine {
+		goroutineID = currentGoroutineID()
+	}
 
 	return LogRecord{
-		Asctime:         created.Format(timeLayout),
 		Created:         created.Unix(),
 		Fields:          fields,
 		FileName:        filepath.Base(pathname),
 		FuncName:        funcname,
+		GoroutineID:     goroutineID,
 		LevelName:       GetLevelName(level),
 		LevelNo:         level,
 		LineNo:          lineno,
@@ -147,13 +159,36 @@ func makeRecord(name string, level int, fields ...field) LogRecord {
 		PathName:        pathname,
 		Process:         processid,
 		RelativeCreated: created.UnixMilli() - startTime,
+		Sequence:        atomic.AddUint64(&sequence, 1),
+		Time:            created,
 	}
 }
 
+// resolveLazyFields evaluates all lazy values (func() any) in the field list,
+// then replaces them with their results. The field list is shared by all
+// Handlers of the record, so every lazy value is evaluated at most once.
+func resolveLazyFields(fields []field) {
+	for i := range fields {
+		if f, ok := fields[i].value.(func() any); ok {
+			fields[i].value = f()
+		}
+	}
+}
+
+// isLazy checks if the value of a field is lazy, see resolveLazyFields.
+func isLazy(f field) bool {
+	var _, ok = f.value.(func() any)
+	return ok
+}
+
 // extractFromPC returns module name and function name from program counter.
 func extractFromPC(pc uintptr) (string, string) {
-	var s = runtime.FuncForPC(pc).Name()
+	return splitFuncName(runtime.FuncForPC(pc).Name())
+}
 
+// splitFuncName splits a fully qualified function name into module name and
+// function name.
+func splitFuncName(s string) (string, string) {
 	var moduleIdx = -1
 	for i := range s {
 		if s[i] == '.' && moduleIdx == -1 {
@@ -171,3 +206,28 @@ func extractFromPC(pc uintptr) (string, string) {
 	return s[0:moduleIdx], s[moduleIdx+1:]
 
 }
+
+// currentGoroutineID parses the ID of current goroutine from its stack trace,
+// which starts with "goroutine <id> [".
+func currentGoroutineID() int64 {
+	var buf [64]byte
+	var s = buf[:runtime.Stack(buf[:], false)]
+	s = s[len("goroutine "):]
+	for i := range s {
+		if s[i] == ' ' {
+			s = s[:i]
+			break
+		}
+	}
+
+	var id, err = strconv.ParseInt(string(s), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// fieldKV returns the key and the value of the field.
+func fieldKV(f field) (string, any) {
+	return f.key, f.value
+}
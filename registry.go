@@ -0,0 +1,370 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xylock"
+)
+
+// defaultRegistry is the Registry used by package-level functions.
+var defaultRegistry = NewRegistry()
+
+// Registry owns an independent logger hierarchy, named Handlers, Emitters and
+// settings. Loggers got from different Registries never share Handlers or
+// configuration, so two libraries or two parallel tests can configure their
+// own logging independently.
+//
+// Package-level functions such as GetLogger, GetHandler, NewStreamEmitter and
+// SetFindCaller work on the default Registry. Level names, macros, context
+// extractors and severities are shared by all Registries.
+type Registry struct {
+	lock *xylock.RWLock
+
+	// root is the parent Logger of all Loggers in this Registry.
+	root *Logger
+
+	// handlers is a map to search Handler by name.
+	handlers map[string]*Handler
+
+	// emitters is a list containing all Emitters created by this Registry.
+	emitters []Emitter
+
+	// settings is the current *settings. It is never modified after being
+	// stored, so it is loaded without locking when creating LogRecords.
+	settings atomic.Value
+
+	// vmodule is the current *vmodule, it is loaded without locking by
+	// Logger.V.
+	vmodule atomic.Value
+
+	// levelRules is the current *levelRuleSet, it is loaded without locking
+	// when computing effective levels of Loggers.
+	levelRules atomic.Value
+
+	// stopAutoPrune stops the goroutine pruning idle loggers. It is nil if the
+	// auto pruning is disabled.
+	stopAutoPrune chan struct{}
+}
+
+// settings contains settings of a Registry.
+type settings struct {
+	// timeLayout is the default time layout of newly created Handlers.
+	timeLayout string
+
+	// skipCall is the depth of Logger.log call in program, 3 by default.
+	skipCall int
+
+	// findCaller allows finding caller information including filename,
+	// lineno, funcname, module.
+	findCaller bool
+
+	// findGoroutine allows finding the ID of goroutine which logs the record.
+	findGoroutine bool
+
+	// clock provides the creation time of LogRecords.
+	clock Clock
+
+	// startTime is used as the base when calculating the relative time of
+	// events.
+	startTime int64
+}
+
+// defaultSettings returns settings of a newly created Registry.
+func defaultSettings() *settings {
+	var clock = systemClock{}
+	return &settings{
+		timeLayout: time.RFC3339Nano,
+		skipCall:   3,
+		clock:      clock,
+		startTime:  clock.Now().UnixMilli(),
+	}
+}
+
+// NewRegistry creates a Registry with default settings. Its root Logger has
+// WARNING level and no Handler.
+func NewRegistry() *Registry {
+	var r = &Registry{
+		lock:     &xylock.RWLock{},
+		handlers: make(map[string]*Handler),
+	}
+	r.settings.Store(defaultSettings())
+	r.root = newLogger("", nil, r)
+	r.root.SetLevel(WARNING)
+	r.vmodule.Store(newVModule(0, "", nil))
+	r.levelRules.Store(&levelRuleSet{})
+	return r
+}
+
+// DefaultRegistry returns the Registry used by package-level functions.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// GetLogger gets a logger of this Registry with the specified name, creating
+// it if it doesn't yet exist. See the package-level GetLogger for details.
+func (r *Registry) GetLogger(name string) *Logger {
+	if name == "" {
+		return r.root
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var lg = r.root
+	for _, part := range strings.Split(name, ".") {
+		if _, ok := lg.children[part]; !ok {
+			lg.children[part] = newLogger(part, lg, r)
+		}
+		lg = lg.children[part]
+	}
+	lg.touch(r.loadSettings().clock.Now())
+	return lg
+}
+
+// RemoveLogger removes the logger with the specified name and all its
+// descendants from this Registry. See the package-level RemoveLogger for
+// details.
+func (r *Registry) RemoveLogger(name string) {
+	xycond.AssertNotEmpty(name)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var parts = strings.Split(name, ".")
+	var parent = r.root
+	for _, part := range parts[:len(parts)-1] {
+		var child, ok = parent.children[part]
+		if !ok {
+			return
+		}
+		parent = child
+	}
+
+	var key = parts[len(parts)-1]
+	if _, ok := parent.children[key]; ok {
+		parent.lock.WLockFunc(func() { delete(parent.children, key) })
+	}
+}
+
+// PruneLoggers removes idle leaf loggers from this Registry and returns the
+// number of removed loggers. See the package-level PruneLoggers for details.
+func (r *Registry) PruneLoggers(idle time.Duration) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.root.prune(r.loadSettings().clock.Now().Add(-idle).UnixNano())
+}
+
+// SetAutoPrune starts pruning idle leaf loggers of this Registry periodically
+// as PruneLoggers does. Use a non-positive duration to stop it.
+func (r *Registry) SetAutoPrune(idle time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stopAutoPrune != nil {
+		close(r.stopAutoPrune)
+		r.stopAutoPrune = nil
+	}
+
+	if idle > 0 {
+		r.stopAutoPrune = make(chan struct{})
+		go r.autoPrune(idle, r.stopAutoPrune)
+	}
+}
+
+// GetHandler gets a handler of this Registry with the specified name, creating
+// it if it doesn't yet exist. See the package-level GetHandler for details.
+func (r *Registry) GetHandler(name string) *Handler {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if h, ok := r.handlers[name]; ok {
+		return h
+	}
+
+	var h = newHandler(name, r.loadSettings().timeLayout)
+	if name != "" {
+		r.handlers[name] = h
+	}
+
+	return h
+}
+
+// RemoveHandler removes the Handler with the specified name from this Registry
+// and all its Loggers. See the package-level RemoveHandler for details.
+func (r *Registry) RemoveHandler(name string) {
+	xycond.AssertNotEmpty(name)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if h, ok := r.handlers[name]; ok {
+		delete(r.handlers, name)
+		r.root.replaceHandler(h, nil)
+		invalidateCaches()
+	}
+}
+
+// ReplaceHandler associates the name with the given anonymous Handler in this
+// Registry. See the package-level ReplaceHandler for details.
+func (r *Registry) ReplaceHandler(name string, h *Handler) *Handler {
+	xycond.AssertNotEmpty(name)
+	xycond.AssertNotNil(h)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var old = r.handlers[name]
+	if old == h {
+		return nil
+	}
+
+	h.lock.WLockFunc(func() {
+		xycond.AssertEmpty(h.name)
+		h.name = name
+	})
+	r.handlers[name] = h
+
+	if old != nil {
+		r.root.replaceHandler(old, h)
+		invalidateCaches()
+	}
+	return old
+}
+
+// Handlers returns all named Handlers of this Registry, sorted by name.
+func (r *Registry) Handlers() []*Handler {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var names = make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var handlers = make([]*Handler, 0, len(names))
+	for i := range names {
+		handlers = append(handlers, r.handlers[names[i]])
+	}
+	return handlers
+}
+
+// NewBufferEmitter creates a StreamEmitter which uses a Buffered Writer. The
+// Emitter is flushed by Registry.Flush.
+func (r *Registry) NewBufferEmitter(w io.Writer, bufsize int) *StreamEmitter {
+	var e = newBufferEmitter(w, bufsize)
+	r.lock.WLockFunc(func() { r.emitters = append(r.emitters, e) })
+	return e
+}
+
+// NewStreamEmitter creates a StreamEmitter which writes logging message to a
+// stream. The Emitter is flushed by Registry.Flush.
+func (r *Registry) NewStreamEmitter(w io.Writer) *StreamEmitter {
+	return r.NewBufferEmitter(w, 0)
+}
+
+// Flush writes unflushed buffered data of all Emitters created by this
+// Registry to outputs.
+func (r *Registry) Flush() {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for i := range r.emitters {
+		r.emitters[i].Flush()
+	}
+}
+
+// SetSkipCall sets the new skipCall value which dertermine the depth call of
+// Logger.log method.
+func (r *Registry) SetSkipCall(skip int) {
+	r.updateSettings(func(s *settings) { s.skipCall = skip })
+}
+
+// SetTimeLayout sets the default time layout of Handlers created by this
+// Registry after this call.
+func (r *Registry) SetTimeLayout(layout string) {
+	r.updateSettings(func(s *settings) { s.timeLayout = layout })
+}
+
+// SetFindCaller with true to find caller information including filename, line
+// number, function name, and module.
+func (r *Registry) SetFindCaller(b bool) {
+	r.updateSettings(func(s *settings) { s.findCaller = b })
+}
+
+// SetFindGoroutine with true to find the ID of goroutine which logs the
+// record.
+func (r *Registry) SetFindGoroutine(b bool) {
+	r.updateSettings(func(s *settings) { s.findGoroutine = b })
+}
+
+// SetClock sets the Clock used to create LogRecords of this Registry. The
+// relative creation time of records is measured from this call. Use nil to
+// restore the system clock.
+func (r *Registry) SetClock(c Clock) {
+	if c == nil {
+		c = systemClock{}
+	}
+
+	r.updateSettings(func(s *settings) {
+		s.clock = c
+		s.startTime = c.Now().UnixMilli()
+	})
+}
+
+// loadSettings returns the current settings of this Registry. The returned
+// settings must not be modified.
+func (r *Registry) loadSettings() *settings {
+	return r.settings.Load().(*settings)
+}
+
+// updateSettings modifies a copy of the current settings by f, then stores the
+// copy as the current settings.
+func (r *Registry) updateSettings(f func(s *settings)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var s = *r.loadSettings()
+	f(&s)
+	r.settings.Store(&s)
+}
+
+// autoPrune calls PruneLoggers every idle duration until stop is closed.
+func (r *Registry) autoPrune(idle time.Duration, stop chan struct{}) {
+	var ticker = time.NewTicker(idle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.PruneLoggers(idle)
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	var registry = xylog.DefaultRegistry()
+	xycond.ExpectEqual(registry.GetLogger(t.Name()), xylog.GetLogger(t.Name())).
+		Test(t)
+	xycond.ExpectEqual(registry.GetLogger(""), xylog.GetLogger("")).Test(t)
+	xycond.ExpectEqual(registry.GetHandler(t.Name()), xylog.GetHandler(t.Name())).
+		Test(t)
+}
+
+func TestNewRegistry(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var root = registry.GetLogger("")
+	xycond.ExpectEqual(root.Name(), "").Test(t)
+	xycond.ExpectEqual(root.Level(), xylog.WARNING).Test(t)
+	xycond.ExpectNil(root.Parent()).Test(t)
+	xycond.ExpectEmpty(root.Handlers()).Test(t)
+
+	var lg = registry.GetLogger("a.b")
+	xycond.ExpectEqual(lg.Name(), "a.b").Test(t)
+	xycond.ExpectEqual(lg.Parent(), registry.GetLogger("a")).Test(t)
+	xycond.ExpectEqual(lg.Parent().Parent(), root).Test(t)
+}
+
+func TestRegistryIsolation(t *testing.T) {
+	var r1, r2 = xylog.NewRegistry(), xylog.NewRegistry()
+
+	xycond.ExpectNotEqual(r1.GetLogger(""), r2.GetLogger("")).Test(t)
+	xycond.ExpectNotEqual(r1.GetLogger("a"), r2.GetLogger("a")).Test(t)
+	xycond.ExpectNotEqual(r1.GetLogger("a"), xylog.GetLogger("a")).Test(t)
+	xycond.ExpectNotEqual(r1.GetHandler("a"), r2.GetHandler("a")).Test(t)
+	xycond.ExpectEqual(r1.GetHandler("a"), r1.GetHandler("a")).Test(t)
+
+	var w1, w2 = &test.MockWriter{}, &test.MockWriter{}
+	var h1, h2 = r1.GetHandler(""), r2.GetHandler("")
+	h1.AddEmitter(r1.NewStreamEmitter(w1))
+	h2.AddEmitter(r2.NewStreamEmitter(w2))
+	r1.GetLogger("").AddHandler(h1)
+	r2.GetLogger("").AddHandler(h2)
+
+	r2.GetLogger("").SetLevel(xylog.DEBUG)
+	xycond.ExpectEqual(r1.GetLogger("a").Level(), xylog.NOTSET).Test(t)
+	xycond.ExpectFalse(r1.GetLogger("a").Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectTrue(r2.GetLogger("a").Enabled(xylog.DEBUG)).Test(t)
+
+	r1.GetLogger("a").Error("foo")
+	xycond.ExpectEqual(w1.Captured, "messsage=foo\n").Test(t)
+	xycond.ExpectEmpty(w2.Captured).Test(t)
+}
+
+func TestRegistrySettings(t *testing.T) {
+	var r1, r2 = xylog.NewRegistry(), xylog.NewRegistry()
+	r1.SetFindCaller(true)
+	r1.SetTimeLayout(xylog.UnixLayout)
+	r1.SetClock(test.NewFakeClock(time.Unix(100, 0)))
+
+	for _, r := range []*xylog.Registry{r1, r2} {
+		var h = r.GetHandler("")
+		h.AddMacro("lineno", "lineno")
+		h.AddMacro("asctime", "asctime")
+		r.GetLogger("").AddHandler(h)
+	}
+
+	var w1, w2 = &test.MockWriter{}, &test.MockWriter{}
+	r1.GetLogger("").Handlers()[0].AddEmitter(r1.NewStreamEmitter(w1))
+	r2.GetLogger("").Handlers()[0].AddEmitter(r2.NewStreamEmitter(w2))
+
+	r1.GetLogger(t.Name()).Error("foo")
+	r2.GetLogger(t.Name()).Error("foo")
+	xycond.ExpectNotIn("lineno=-1", w1.Captured).Test(t)
+	xycond.ExpectIn("asctime=100 ", w1.Captured).Test(t)
+	xycond.ExpectIn("lineno=0", w2.Captured).Test(t)
+	xycond.ExpectNotIn("asctime=100 ", w2.Captured).Test(t)
+}
+
+func TestRegistryFlush(t *testing.T) {
+	var r1, r2 = xylog.NewRegistry(), xylog.NewRegistry()
+	var b1, b2 = &bytes.Buffer{}, &bytes.Buffer{}
+	var e1, e2 = r1.NewBufferEmitter(b1, 1024), r2.NewBufferEmitter(b2, 1024)
+	e1.Emit([]byte("foo"))
+	e2.Emit([]byte("bar"))
+
+	r1.Flush()
+	xycond.ExpectEqual(b1.String(), "foo\n").Test(t)
+	xycond.ExpectEmpty(b2.String()).Test(t)
+
+	r2.Flush()
+	xycond.ExpectEqual(b2.String(), "bar\n").Test(t)
+}
+
+func TestRegistryRemoveAndPruneLoggers(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var lg = registry.GetLogger("a.b")
+	xylog.GetLogger("a.b")
+
+	registry.RemoveLogger("a")
+	xycond.ExpectNil(registry.GetLogger("a").Children()).Test(t)
+	xycond.ExpectNotEqual(registry.GetLogger("a.b"), lg).Test(t)
+	xycond.ExpectNotNil(xylog.GetLogger("a").Children()).Test(t)
+
+	registry.SetClock(test.NewFakeClock(time.Now().Add(time.Hour)))
+	xycond.ExpectEqual(registry.PruneLoggers(time.Minute), 2).Test(t)
+}
+
+func TestSimpleConfigRegistry(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var w = &test.MockWriter{}
+	var logger, err = xylog.SimpleConfig{
+		Name:     t.Name(),
+		Registry: registry,
+		Writer:   w,
+	}.Apply()
+	xycond.ExpectNil(err).Test(t)
+	xycond.ExpectEqual(logger, registry.GetLogger(t.Name())).Test(t)
+	xycond.ExpectNotEqual(logger, xylog.GetLogger(t.Name())).Test(t)
+}
+
+func TestRegistrySettingsConcurrently(t *testing.T) {
+	var registry = xylog.NewRegistry()
+	var w = &test.MockWriter{}
+	var h = registry.GetHandler("")
+	h.AddMacro("lineno", "lineno")
+	h.AddEmitter(registry.NewStreamEmitter(w))
+	var logger = registry.GetLogger(t.Name())
+	logger.AddHandler(h)
+
+	var done = make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			logger.Error("foo")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		registry.SetFindCaller(true)
+		registry.SetFindCaller(false)
+	}
+	<-done
+
+	w.Reset()
+	registry.SetFindCaller(true)
+	logger.Error("foo")
+	xycond.ExpectNotIn("lineno=0", w.Captured).Test(t)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+func init() {
+	severityTable.Store(newSeverityTable(levelToSeverity))
+}
+
+// Severity is the severity of a logging level in other logging systems.
+type Severity struct {
+	// Syslog is the severity of RFC 5424, from 0 (Emergency) to 7 (Debug).
+	Syslog int
+
+	// OTel is the SeverityNumber of OpenTelemetry logs data model, from 1
+	// (TRACE) to 24 (FATAL4).
+	OTel int
+}
+
+// levelToSeverity maps levels to their Severities, see GetSeverity.
+var levelToSeverity = map[int]Severity{
+	CRITICAL: {Syslog: 2, OTel: 21},
+	ERROR:    {Syslog: 3, OTel: 17},
+	WARNING:  {Syslog: 4, OTel: 13},
+	NOTICE:   {Syslog: 5, OTel: 10},
+	INFO:     {Syslog: 6, OTel: 9},
+	DEBUG:    {Syslog: 7, OTel: 5},
+	TRACE:    {Syslog: 7, OTel: 1},
+}
+
+// severityThreshold is a level which is associated with a Severity.
+type severityThreshold struct {
+	level    int
+	severity Severity
+}
+
+// severityTable is the current []severityThreshold built from levelToSeverity
+// and sorted by level. It is never modified after being stored, so GetSeverity
+// loads it without locking.
+var severityTable atomic.Value
+
+// SetSeverity associates a level with a Severity. It can overwrite the default
+// mapping.
+func SetSeverity(level int, s Severity) {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+
+	levelToSeverity[level] = s
+	severityTable.Store(newSeverityTable(levelToSeverity))
+}
+
+// GetSeverity returns the Severity of a level. A level which has no associated
+// Severity uses the one of the nearest lower level, e.g. a custom level 45 has
+// the Severity of ERROR. A level lower than all associated levels uses the
+// Severity of the lowest one (TRACE by default).
+//
+// The default mapping:
+//
+//	Level     Syslog             OTel
+//	CRITICAL  2 (Critical)       21 (FATAL)
+//	ERROR     3 (Error)          17 (ERROR)
+//	WARNING   4 (Warning)        13 (WARN)
+//	NOTICE    5 (Notice)         10 (INFO2)
+//	INFO      6 (Informational)  9  (INFO)
+//	DEBUG     7 (Debug)          5  (DEBUG)
+//	TRACE     7 (Debug)          1  (TRACE)
+func GetSeverity(level int) Severity {
+	var table = severityTable.Load().([]severityThreshold)
+	var i = sort.Search(len(table), func(i int) bool {
+		return table[i].level > level
+	})
+
+	if i == 0 {
+		return table[0].severity
+	}
+	return table[i-1].severity
+}
+
+// newSeverityTable returns the thresholds of the mapping sorted by level.
+func newSeverityTable(m map[int]Severity) []severityThreshold {
+	var table = make([]severityThreshold, 0, len(m))
+	for level, s := range m {
+		table = append(table, severityThreshold{level: level, severity: s})
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].level < table[j].level
+	})
+	return table
+}
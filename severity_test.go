@@ -0,0 +1,78 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"testing"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func TestGetSeverity(t *testing.T) {
+	var cases = map[int]xylog.Severity{
+		xylog.CRITICAL: {Syslog: 2, OTel: 21},
+		xylog.ERROR:    {Syslog: 3, OTel: 17},
+		xylog.WARNING:  {Syslog: 4, OTel: 13},
+		xylog.NOTICE:   {Syslog: 5, OTel: 10},
+		xylog.INFO:     {Syslog: 6, OTel: 9},
+		xylog.DEBUG:    {Syslog: 7, OTel: 5},
+		xylog.TRACE:    {Syslog: 7, OTel: 1},
+		45:             {Syslog: 3, OTel: 17},
+		100:            {Syslog: 2, OTel: 21},
+		xylog.NOTSET:   {Syslog: 7, OTel: 1},
+	}
+
+	for level, expected := range cases {
+		xycond.ExpectEqual(xylog.GetSeverity(level), expected).Test(t)
+	}
+}
+
+func TestSetSeverity(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xylog.SetSeverity(131, xylog.Severity{Syslog: 1, OTel: 23})
+	xycond.ExpectEqual(xylog.GetSeverity(131),
+		xylog.Severity{Syslog: 1, OTel: 23}).Test(t)
+	xycond.ExpectEqual(xylog.GetSeverity(132),
+		xylog.Severity{Syslog: 1, OTel: 23}).Test(t)
+
+	xylog.SetSeverity(35, xylog.Severity{Syslog: 4, OTel: 15})
+	xycond.ExpectEqual(xylog.GetSeverity(39),
+		xylog.Severity{Syslog: 4, OTel: 15}).Test(t)
+	xycond.ExpectEqual(xylog.GetSeverity(34),
+		xylog.Severity{Syslog: 4, OTel: 13}).Test(t)
+}
+
+func TestSeverityMacros(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		var handler = logger.Handlers()[0]
+		handler.AddMacro("syslog", "syslogSeverity")
+		handler.AddMacro("otel", "otelSeverity")
+		logger.SetLevel(xylog.NOTICE)
+
+		logger.Notice("foo")
+		xycond.ExpectIn("syslog=5", w.Captured).Test(t)
+		xycond.ExpectIn("otel=10", w.Captured).Test(t)
+	})
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import "github.com/xybor-x/xycond"
+
+// State is the configuration of a Registry at a point of time, including the
+// logger hierarchy, the level, Handlers, Filters, fields and flags of every
+// Logger, named Handlers, Emitters, level rules, verbosity and settings. It is
+// created by Snapshot and brought back by Restore.
+//
+// Level names, macros, context extractors and severities are shared by all
+// Registries, so they are only a part of States created by the package-level
+// Snapshot.
+//
+// The configuration inside Handlers (level, macros, Emitters...) is not a part
+// of State. A temporary level set by Logger.SetLevelFor is not a part of State
+// either, the level before it is captured instead, and Restore cancels active
+// temporary levels.
+type State struct {
+	registry *Registry
+	settings settings
+	vmodule  *vmodule
+	rules    *levelRuleSet
+	handlers map[string]*Handler
+	emitters []Emitter
+	loggers  []loggerState
+
+	// globals is nil if the State is created by Registry.Snapshot.
+	globals *globalState
+}
+
+// globalState is the configuration shared by all Registries.
+type globalState struct {
+	levelNames map[int]string
+	macros     map[string]MacroFunc
+	extractors []ContextExtractor
+	severities map[int]Severity
+}
+
+// defaultLevelNames is the built-in level names, it is brought back by the
+// package-level Reset.
+var defaultLevelNames map[int]string
+
+// defaultSeverities is the built-in severities, it is brought back by the
+// package-level Reset.
+var defaultSeverities map[int]Severity
+
+// init captures the built-in configuration after the package is initialized.
+func init() {
+	var g = saveGlobals()
+	defaultLevelNames, defaultSeverities = g.levelNames, g.severities
+}
+
+// loggerState is the configuration of a Logger in State.
+type loggerState struct {
+	lg        *Logger
+	parent    *Logger
+	children  map[string]*Logger
+	level     int
+	handlers  []*Handler
+	filters   []Filter
+	fields    []field
+	propagate bool
+	disabled  bool
+}
+
+// Snapshot captures the current configuration of the default Registry.
+//
+// It is useful in tests to restore the configuration after each test:
+//
+//	var state = xylog.Snapshot()
+//	t.Cleanup(func() { xylog.Restore(state) })
+func Snapshot() *State {
+	var s = defaultRegistry.Snapshot()
+	s.globals = saveGlobals()
+	return s
+}
+
+// Restore brings the configuration of the default Registry, level names,
+// macros, context extractors and severities back to the given State.
+func Restore(s *State) {
+	defaultRegistry.Restore(s)
+	if s.globals != nil {
+		s.globals.restore()
+	}
+}
+
+// Reset brings the default Registry, level names and severities back to their
+// initial configuration. Macros and context extractors are kept, since they
+// are usually registered in init functions of other packages.
+func Reset() {
+	defaultRegistry.Reset()
+
+	globalLock.Lock()
+	defer globalLock.Unlock()
+	levelToName = copyMap(defaultLevelNames)
+	levelToSeverity = copyMap(defaultSeverities)
+	severityTable.Store(newSeverityTable(levelToSeverity))
+}
+
+// Snapshot captures the current configuration of this Registry.
+func (r *Registry) Snapshot() *State {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var s = &State{
+		registry: r,
+		settings: *r.loadSettings(),
+		vmodule:  r.loadVModule(),
+		rules:    r.loadLevelRules(),
+		handlers: make(map[string]*Handler, len(r.handlers)),
+		emitters: append([]Emitter(nil), r.emitters...),
+	}
+	for name, h := range r.handlers {
+		s.handlers[name] = h
+	}
+	s.loggers = r.root.saveTree(s.loggers)
+
+	return s
+}
+
+// Restore brings the configuration of this Registry back to the given State.
+// Loggers created after the State was captured are removed from the logger
+// hierarchy as RemoveLogger does, and removed Loggers come back.
+func (r *Registry) Restore(s *State) {
+	xycond.AssertNotNil(s)
+	xycond.AssertEqual(s.registry, r)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var settings = s.settings
+	r.settings.Store(&settings)
+	r.vmodule.Store(s.vmodule)
+	r.levelRules.Store(s.rules)
+	r.handlers = make(map[string]*Handler, len(s.handlers))
+	for name, h := range s.handlers {
+		r.handlers[name] = h
+	}
+	r.emitters = append([]Emitter(nil), s.emitters...)
+
+	var saved = make(map[*Logger]bool, len(s.loggers))
+	for i := range s.loggers {
+		saved[s.loggers[i].lg] = true
+	}
+
+	r.root.stopUnsavedOverrides(saved)
+	for i := range s.loggers {
+		s.loggers[i].restore()
+	}
+
+	invalidateCaches()
+}
+
+// Reset brings this Registry back to its initial configuration. All Loggers
+// except the root one are removed from the logger hierarchy as RemoveLogger
+// does, named Handlers and Emitters are forgotten, the verbosity is reset,
+// active temporary levels are cancelled, and the auto pruning is stopped.
+func (r *Registry) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.stopAutoPrune != nil {
+		close(r.stopAutoPrune)
+		r.stopAutoPrune = nil
+	}
+
+	r.settings.Store(defaultSettings())
+	r.vmodule.Store(newVModule(0, "", nil))
+	r.levelRules.Store(&levelRuleSet{})
+	r.handlers = make(map[string]*Handler)
+	r.emitters = nil
+
+	var children = r.root.Children()
+	var root = loggerState{
+		lg:        r.root,
+		children:  make(map[string]*Logger),
+		level:     WARNING,
+		propagate: true,
+	}
+	root.restore()
+	for i := range children {
+		children[i].stopOverrides()
+	}
+	invalidateCaches()
+}
+
+// saveTree appends the configuration of this Logger and all its descendants to
+// the list.
+func (lg *Logger) saveTree(states []loggerState) []loggerState {
+	lg.lock.RLock()
+	var s = loggerState{
+		lg:        lg,
+		parent:    lg.parent,
+		children:  make(map[string]*Logger, len(lg.children)),
+		level:     lg.level,
+		handlers:  append([]*Handler(nil), lg.handlers...),
+		filters:   append([]Filter(nil), lg.f.filters...),
+		fields:    append([]field(nil), lg.fields...),
+		propagate: lg.propagate,
+		disabled:  lg.disabled,
+	}
+	for key, child := range lg.children {
+		s.children[key] = child
+	}
+	if lg.override != nil {
+		s.level = lg.override.Previous
+	}
+	lg.lock.RUnlock()
+
+	states = append(states, s)
+	for _, child := range s.children {
+		states = child.saveTree(states)
+	}
+	return states
+}
+
+// stopUnsavedOverrides cancels active overrides of descendants of this Logger
+// which are not saved, since they are removed from the logger hierarchy.
+func (lg *Logger) stopUnsavedOverrides(saved map[*Logger]bool) {
+	for _, child := range lg.Children() {
+		if saved[child] {
+			child.stopUnsavedOverrides(saved)
+		} else {
+			child.stopOverrides()
+		}
+	}
+}
+
+// restore brings the configuration of the Logger back.
+func (s loggerState) restore() {
+	var lg = s.lg
+	lg.lock.Lock()
+	defer lg.lock.Unlock()
+
+	lg.parent = s.parent
+	lg.children = make(map[string]*Logger, len(s.children))
+	for key, child := range s.children {
+		lg.children[key] = child
+	}
+	lg.stopOverride()
+	lg.level = s.level
+	lg.handlers = append([]*Handler(nil), s.handlers...)
+	lg.f.filters = append([]Filter(nil), s.filters...)
+	lg.fields = append([]field(nil), s.fields...)
+	lg.propagate = s.propagate
+	lg.disabled = s.disabled
+}
+
+// saveGlobals captures the configuration shared by all Registries.
+func saveGlobals() *globalState {
+	globalLock.RLock()
+	defer globalLock.RUnlock()
+
+	return &globalState{
+		levelNames: copyMap(levelToName),
+		macros:     copyMap(macroRegistry),
+		extractors: contextExtractors,
+		severities: copyMap(levelToSeverity),
+	}
+}
+
+// restore brings the configuration shared by all Registries back. The maps
+// are copied, so the globalState can be restored many times.
+func (g *globalState) restore() {
+	globalLock.Lock()
+	defer globalLock.Unlock()
+
+	levelToName = copyMap(g.levelNames)
+	macroRegistry = copyMap(g.macros)
+	contextExtractors = g.extractors
+	levelToSeverity = copyMap(g.severities)
+	severityTable.Store(newSeverityTable(levelToSeverity))
+}
+
+// copyMap returns a shallow copy of the map.
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	var c = make(map[K]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
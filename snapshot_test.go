@@ -0,0 +1,215 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var lg = xylog.GetLogger(t.Name())
+	var handler = xylog.GetHandler(t.Name())
+	lg.AddHandler(handler)
+	lg.SetLevel(xylog.DEBUG)
+	lg.AddField("foo", "bar")
+	lg.AddFilter(&test.LoggerNameFilter{Name: t.Name()})
+	lg.SetPropagate(false)
+
+	var inner = xylog.Snapshot()
+	var child = xylog.GetLogger(t.Name() + ".child")
+	lg.RemoveAllHandlers()
+	lg.AddHandler(xylog.GetHandler(""))
+	lg.SetLevel(xylog.ERROR)
+	lg.AddField("buzz", "quz")
+	lg.RemoveFilter(lg.Filters()[0])
+	lg.SetPropagate(true)
+	lg.SetDisabled(true)
+	xylog.GetHandler(t.Name() + "-new")
+
+	xylog.Restore(inner)
+	xycond.ExpectEqual(xylog.GetLogger(t.Name()), lg).Test(t)
+	xycond.ExpectEqual(len(lg.Handlers()), 1).Test(t)
+	xycond.ExpectEqual(lg.Handlers()[0], handler).Test(t)
+	xycond.ExpectEqual(lg.Level(), xylog.DEBUG).Test(t)
+	xycond.ExpectEqual(len(lg.Filters()), 1).Test(t)
+	xycond.ExpectFalse(lg.Propagate()).Test(t)
+	xycond.ExpectFalse(lg.Disabled()).Test(t)
+	xycond.ExpectTrue(lg.Enabled(xylog.DEBUG)).Test(t)
+	xycond.ExpectEqual(xylog.GetHandler(t.Name()), handler).Test(t)
+
+	xycond.ExpectEqual(child.Parent(), lg).Test(t)
+	xycond.ExpectEmpty(lg.Children()).Test(t)
+	xycond.ExpectNotEqual(xylog.GetLogger(t.Name()+".child"), child).Test(t)
+
+	xylog.Restore(state)
+	xycond.ExpectEqual(lg.Parent(), xylog.GetLogger("")).Test(t)
+	xycond.ExpectNotEqual(xylog.GetLogger(t.Name()), lg).Test(t)
+	xycond.ExpectNotEqual(xylog.GetHandler(t.Name()), handler).Test(t)
+}
+
+func TestSnapshotRestoreRemovedLogger(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var lg = xylog.GetLogger(t.Name() + ".child")
+	var inner = xylog.Snapshot()
+	xylog.RemoveLogger(t.Name())
+
+	xylog.Restore(inner)
+	xycond.ExpectEqual(xylog.GetLogger(t.Name()+".child"), lg).Test(t)
+	xycond.ExpectEqual(lg.Parent(), xylog.GetLogger(t.Name())).Test(t)
+}
+
+func TestSnapshotRestoreSettings(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xylog.SetTimeLayout(xylog.UnixLayout)
+	xylog.SetClock(test.NewFakeClock(time.Unix(100, 0)))
+	var inner = xylog.Snapshot()
+	xylog.SetTimeLayout(time.RFC1123)
+	xylog.SetClock(nil)
+
+	xylog.Restore(inner)
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.Handlers()[0].AddMacro("asctime", "asctime")
+		logger.Error("foo")
+		xycond.ExpectEqual(w.Captured, "asctime=100 messsage=foo\n").Test(t)
+	})
+}
+
+func TestSnapshotRestoreEmitters(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var buf = &bytes.Buffer{}
+	var emitter = xylog.NewBufferEmitter(buf, 1024)
+	xylog.Restore(state)
+
+	emitter.Emit([]byte("foo"))
+	xylog.Flush()
+	xycond.ExpectEmpty(buf.String()).Test(t)
+}
+
+func TestSnapshotRestoreAnotherRegistry(t *testing.T) {
+	var state = xylog.NewRegistry().Snapshot()
+	xycond.ExpectPanic(xyerror.AssertionError, func() {
+		xylog.Restore(state)
+	}).Test(t)
+}
+
+func TestReset(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var root = xylog.GetLogger("")
+	var lg = xylog.GetLogger(t.Name())
+	var handler = xylog.GetHandler(t.Name())
+	root.AddHandler(handler)
+	root.SetLevel(xylog.DEBUG)
+	xylog.SetFindCaller(true)
+
+	xylog.Reset()
+	xycond.ExpectEqual(xylog.GetLogger(""), root).Test(t)
+	xycond.ExpectEqual(root.Level(), xylog.WARNING).Test(t)
+	xycond.ExpectEmpty(root.Handlers()).Test(t)
+	xycond.ExpectEmpty(root.Children()).Test(t)
+	xycond.ExpectEqual(lg.Parent(), root).Test(t)
+	xycond.ExpectNotEqual(xylog.GetHandler(t.Name()), handler).Test(t)
+
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.Handlers()[0].AddMacro("lineno", "lineno")
+		logger.Error("foo")
+		xycond.ExpectEqual(w.Captured, "lineno=0 messsage=foo\n").Test(t)
+	})
+}
+
+func TestSnapshotRestoreGlobals(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	var macro = func(xylog.LogRecord) any { return "bar" }
+	xylog.AddLevel(45, "CUSTOM")
+	xylog.RegisterMacro(t.Name(), macro)
+	xylog.SetSeverity(45, xylog.Severity{Syslog: 1, OTel: 19})
+	xylog.AddContextExtractor(func(context.Context) (string, any, bool) {
+		return "foo", "bar", true
+	})
+
+	xylog.Restore(state)
+	xycond.ExpectEmpty(xylog.GetLevelName(45)).Test(t)
+	xycond.ExpectEqual(xylog.GetSeverity(45), xylog.GetSeverity(xylog.ERROR)).
+		Test(t)
+	xycond.ExpectPanic(nil, func() { xylog.RegisterMacro(t.Name(), macro) }).
+		Test(t)
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.ErrorContext(context.Background(), "foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestResetGlobals(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xylog.AddLevel(45, "CUSTOM")
+	xylog.SetSeverity(45, xylog.Severity{Syslog: 1, OTel: 19})
+	xylog.RegisterMacro(t.Name(), func(xylog.LogRecord) any { return "bar" })
+
+	xylog.Reset()
+	xycond.ExpectEmpty(xylog.GetLevelName(45)).Test(t)
+	xycond.ExpectEqual(xylog.GetLevelName(xylog.ERROR), "ERROR").Test(t)
+	xycond.ExpectEqual(xylog.GetSeverity(45), xylog.GetSeverity(xylog.ERROR)).
+		Test(t)
+	test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+		xycond.ExpectNil(h.AddMacro("foo", t.Name())).Test(t)
+	})
+}
+
+func TestResetKeepsInitRegistrations(t *testing.T) {
+	var state = xylog.Snapshot()
+	t.Cleanup(func() { xylog.Restore(state) })
+
+	xylog.Reset()
+	var tc, _ = xylog.ParseTraceParent(traceparent, "")
+	var ctx = xylog.ContextWithTrace(context.Background(), tc)
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		xycond.ExpectNil(logger.Handlers()[0].AddMacro("version", "test_version")).
+			Test(t)
+		logger.ErrorContext(ctx, "foo")
+		xycond.ExpectIn("version=v1.2.3 ", w.Captured).Test(t)
+		xycond.ExpectIn("trace_id=4bf92f3577b34da6a3ce929d0e0e4736", w.Captured).
+			Test(t)
+		xycond.ExpectIn("span_id=00f067aa0ba902b7", w.Captured).Test(t)
+		xycond.ExpectIn("trace_flags=01", w.Captured).Test(t)
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package test
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a xylog.TimerClock whose time is frozen and only changed
+// manually. Functions scheduled by AfterFunc are called by Set and Advance when
+// their time comes.
+type FakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	lock   sync.Mutex
+}
+
+// fakeTimer is a function scheduled by FakeClock.AfterFunc.
+type fakeTimer struct {
+	when time.Time
+	f    func()
+}
+
+// NewFakeClock creates a FakeClock frozen at the given time.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{now: t}
+}
+
+// Now returns the frozen time.
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Set freezes the clock at the given time, then calls scheduled functions
+// whose time has come.
+func (c *FakeClock) Set(t time.Time) {
+	c.lock.Lock()
+	c.now = t
+	c.lock.Unlock()
+	c.fire()
+}
+
+// Advance moves the frozen time forward by a duration, then calls scheduled
+// functions whose time has come.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	c.lock.Unlock()
+	c.fire()
+}
+
+// AfterFunc schedules f to be called when the clock reaches the current time
+// plus the duration. The returned function cancels the call.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var timer = &fakeTimer{when: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return func() bool { return c.remove(timer) }
+}
+
+// fire calls scheduled functions whose time has come without holding the lock,
+// so they can use the clock.
+func (c *FakeClock) fire() {
+	c.lock.Lock()
+	var due []*fakeTimer
+	var pending []*fakeTimer
+	for _, timer := range c.timers {
+		if timer.when.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			due = append(due, timer)
+		}
+	}
+	c.timers = pending
+	c.lock.Unlock()
+
+	for _, timer := range due {
+		timer.f()
+	}
+}
+
+// remove cancels a scheduled function, it returns false if the function has
+// already been called or cancelled.
+func (c *FakeClock) remove(timer *fakeTimer) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := range c.timers {
+		if c.timers[i] == timer {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
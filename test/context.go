@@ -23,29 +23,35 @@ package test
 import (
 	"io"
 	"testing"
+	"time"
 
 	"github.com/xybor-x/xylog"
 	"github.com/xybor-x/xylog/encoding"
 )
 
-// WithLogger allows using a Logger created with a MockWriter quickly.
+// WithLogger allows using a Logger created with a MockWriter quickly. The
+// configuration of xylog is restored after calling f.
 func WithLogger(t *testing.T, f func(logger *xylog.Logger, w *MockWriter)) {
+	var state = xylog.Snapshot()
+	defer xylog.Restore(state)
+
 	var writer = &MockWriter{}
 	var emitter = xylog.NewStreamEmitter(writer)
 	var handler = xylog.GetHandler("")
 	handler.AddEmitter(emitter)
 
 	var logger = xylog.GetLogger(t.Name())
-	// Sometimes the testing runs multiple times and this logger will add more
-	// than one handler.
-	logger.RemoveAllHandlers()
 	logger.AddHandler(handler)
 
 	f(logger, writer)
 }
 
-// WithHandler allows using a Handler with MockWriter.
+// WithHandler allows using a Handler with MockWriter. The configuration of
+// xylog is restored after calling f.
 func WithHandler(t *testing.T, f func(h *xylog.Handler, w *MockWriter)) {
+	var state = xylog.Snapshot()
+	defer xylog.Restore(state)
+
 	var writer = &MockWriter{}
 	var emitter = xylog.NewStreamEmitter(writer)
 	var handler = xylog.GetHandler(t.Name())
@@ -62,18 +68,28 @@ func WithStreamEmitter(t *testing.T, f func(e *xylog.StreamEmitter, w *MockWrite
 	f(emitter, writer)
 }
 
-// WithBenchLogger allows using a Logger whose output is io.Discard.
+// WithBenchLogger allows using a Logger whose output is io.Discard. The
+// configuration of xylog is restored after calling f.
 func WithBenchLogger(b *testing.B, f func(logger *xylog.Logger)) {
+	var state = xylog.Snapshot()
+	defer xylog.Restore(state)
+
 	var emitter = xylog.NewBufferEmitter(io.Discard, 4096)
 	var handler = xylog.GetHandler("")
 	handler.AddEmitter(emitter)
 	handler.SetEncoding(encoding.NewJSONEncoding())
 
 	var logger = xylog.GetLogger(b.Name())
-	// Sometimes the testing runs multiple times and this logger will add more
-	// than one handler.
-	logger.RemoveAllHandlers()
 	logger.AddHandler(handler)
 
 	f(logger)
 }
+
+// WithFakeClock replaces the clock of xylog with a FakeClock frozen at the
+// given time, then restores the system clock after calling f.
+func WithFakeClock(t time.Time, f func(c *FakeClock)) {
+	var c = NewFakeClock(t)
+	xylog.SetClock(c)
+	defer xylog.SetClock(nil)
+	f(c)
+}
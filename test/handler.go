@@ -28,8 +28,10 @@ import (
 func AddFullMacros(h *xylog.Handler) {
 	h.AddMacro("asctime", "asctime")
 	h.AddMacro("created", "created")
+	h.AddMacro("createdNanos", "createdNanos")
 	h.AddMacro("filename", "filename")
 	h.AddMacro("funcname", "funcname")
+	h.AddMacro("goroutine", "goroutine")
 	h.AddMacro("levelname", "levelname")
 	h.AddMacro("levelno", "levelno")
 	h.AddMacro("lineno", "lineno")
@@ -38,4 +40,5 @@ func AddFullMacros(h *xylog.Handler) {
 	h.AddMacro("pathname", "pathname")
 	h.AddMacro("process", "process")
 	h.AddMacro("relativeCreated", "relativeCreated")
+	h.AddMacro("sequence", "sequence")
 }
@@ -21,15 +21,17 @@
 package test
 
 import (
+	"time"
+
 	"github.com/xybor-x/xylog"
 )
 
 // FullRecord is the record with all filled fields.
 var FullRecord = xylog.LogRecord{
-	Asctime:         "ASCTIME",
 	Created:         1,
 	FileName:        "FILENAME",
 	FuncName:        "FUNCNAME",
+	GoroutineID:     7,
 	LevelName:       "LEVELNAME",
 	LevelNo:         2,
 	LineNo:          3,
@@ -39,4 +41,6 @@ var FullRecord = xylog.LogRecord{
 	PathName:        "PATHNAME",
 	Process:         5,
 	RelativeCreated: 6,
+	Sequence:        8,
+	Time:            time.Date(2023, 1, 2, 3, 4, 5, 6, time.UTC),
 }
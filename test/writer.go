@@ -29,6 +29,9 @@ type MockWriter struct {
 
 	// Error decides if Write method returns error or not.
 	Error bool
+
+	// Closed is true after Close method is called.
+	Closed bool
 }
 
 // Write append the byte slice to Captured string. It returns n error if Error
@@ -42,8 +45,13 @@ func (w *MockWriter) Write(b []byte) (int, error) {
 	return len(b), nil
 }
 
-// Close is a fake methods.
+// Close marks the MockWriter as closed. It returns an error if Error attribute
+// is true.
 func (w *MockWriter) Close() error {
+	w.Closed = true
+	if w.Error {
+		return xyerror.BaseException.New("mockwriter raised an error")
+	}
 	return nil
 }
 
@@ -0,0 +1,62 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import "time"
+
+// Special time layouts which format the creation time as a number of elapsed
+// units since the Unix epoch instead of a textual time.
+const (
+	UnixLayout      = "unix"
+	UnixMilliLayout = "unixmilli"
+	UnixMicroLayout = "unixmicro"
+	UnixNanoLayout  = "unixnano"
+)
+
+// timeFormat determines how a Handler formats the creation time of records.
+type timeFormat struct {
+	// layout is a time layout or one of the Unix epoch layouts.
+	layout string
+
+	// location is the timezone used to format the time. A nil location keeps
+	// the timezone of the record.
+	location *time.Location
+}
+
+// macro returns a MacroFunc formatting the creation time of records.
+func (tf timeFormat) macro() MacroFunc {
+	switch tf.layout {
+	case UnixLayout:
+		return func(r LogRecord) any { return r.Time.Unix() }
+	case UnixMilliLayout:
+		return func(r LogRecord) any { return r.Time.UnixMilli() }
+	case UnixMicroLayout:
+		return func(r LogRecord) any { return r.Time.UnixMicro() }
+	case UnixNanoLayout:
+		return func(r LogRecord) any { return r.Time.UnixNano() }
+	}
+
+	var layout, location = tf.layout, tf.location
+	if location == nil {
+		return func(r LogRecord) any { return r.Time.Format(layout) }
+	}
+	return func(r LogRecord) any { return r.Time.In(location).Format(layout) }
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/xybor-x/xyerror"
+)
+
+func init() {
+	AddContextExtractor(traceExtractor("trace_id", TraceContext.traceID))
+	AddContextExtractor(traceExtractor("span_id", TraceContext.spanID))
+	AddContextExtractor(traceExtractor("trace_flags", TraceContext.traceFlags))
+}
+
+// Names of HTTP headers defined by W3C Trace Context.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+// TraceContext is the W3C Trace Context of a request. When a context carrying a
+// TraceContext is logged, trace_id, span_id and trace_flags fields are added to
+// the LogRecord.
+type TraceContext struct {
+	// TraceID is the 32-hex-digit ID of the whole trace.
+	TraceID string
+
+	// SpanID is the 16-hex-digit ID of the parent span.
+	SpanID string
+
+	// TraceFlags is the 2-hex-digit trace flags, e.g. "01" for sampled.
+	TraceFlags string
+
+	// TraceState is the vendor-specific trace information.
+	TraceState string
+}
+
+// SpanContext is the subset of a span context used to correlate logs with
+// traces. The span context of OpenTelemetry satisfies it with T=trace.TraceID,
+// S=trace.SpanID, and F=trace.TraceFlags.
+type SpanContext[T, S, F fmt.Stringer] interface {
+	IsValid() bool
+	TraceID() T
+	SpanID() S
+	TraceFlags() F
+}
+
+// traceKey is the key of TraceContext in context.Context.
+type traceKey struct{}
+
+// ParseTraceParent parses the values of traceparent and tracestate headers. The
+// tracestate can be empty.
+func ParseTraceParent(traceparent, tracestate string) (TraceContext, error) {
+	var s = strings.TrimSpace(traceparent)
+	// version(2) - trace-id(32) - parent-id(16) - trace-flags(2)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return TraceContext{}, xyerror.ValueError.Newf(
+			"invalid traceparent %q", traceparent)
+	}
+
+	var version = s[0:2]
+	var tc = TraceContext{
+		TraceID:    s[3:35],
+		SpanID:     s[36:52],
+		TraceFlags: s[53:55],
+		TraceState: strings.TrimSpace(tracestate),
+	}
+
+	// Future versions may append more fields, but version 00 must not.
+	var valid = isHex(version) && version != "ff" &&
+		(len(s) == 55 || (version != "00" && s[55] == '-')) &&
+		isHex(tc.TraceID) && !isZero(tc.TraceID) &&
+		isHex(tc.SpanID) && !isZero(tc.SpanID) &&
+		isHex(tc.TraceFlags)
+	if !valid {
+		return TraceContext{}, xyerror.ValueError.Newf(
+			"invalid traceparent %q", traceparent)
+	}
+
+	return tc, nil
+}
+
+// TraceFromHeader parses the TraceContext from traceparent and tracestate
+// headers of a HTTP request.
+func TraceFromHeader(h http.Header) (TraceContext, error) {
+	return ParseTraceParent(h.Get(TraceParentHeader), h.Get(TraceStateHeader))
+}
+
+// TraceFromSpanContext converts a SpanContext to TraceContext. It returns false
+// if the SpanContext is invalid. Go can not infer the type arguments from the
+// span context of OpenTelemetry, so they must be written explicitly:
+//
+//	xylog.TraceFromSpanContext[trace.TraceID, trace.SpanID, trace.TraceFlags](sc)
+func TraceFromSpanContext[T, S, F fmt.Stringer](
+	sc SpanContext[T, S, F],
+) (TraceContext, bool) {
+	if !sc.IsValid() {
+		return TraceContext{}, false
+	}
+
+	return TraceContext{
+		TraceID:    sc.TraceID().String(),
+		SpanID:     sc.SpanID().String(),
+		TraceFlags: sc.TraceFlags().String(),
+	}, true
+}
+
+// ContextWithTrace returns a copy of the context carrying the TraceContext.
+func ContextWithTrace(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, tc)
+}
+
+// TraceFromContext returns the TraceContext carried by the context.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	var tc, ok = ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+// TraceParent returns the value of traceparent header with version 00.
+func (tc TraceContext) TraceParent() string {
+	return "00-" + tc.TraceID + "-" + tc.SpanID + "-" + tc.TraceFlags
+}
+
+func (tc TraceContext) traceID() string    { return tc.TraceID }
+func (tc TraceContext) spanID() string     { return tc.SpanID }
+func (tc TraceContext) traceFlags() string { return tc.TraceFlags }
+
+// traceExtractor creates a ContextExtractor which extracts a field of the
+// TraceContext carried by the context.
+func traceExtractor(
+	key string, get func(TraceContext) string,
+) ContextExtractor {
+	return func(ctx context.Context) (string, any, bool) {
+		var tc, ok = TraceFromContext(ctx)
+		return key, get(tc), ok
+	}
+}
+
+// traceMacro creates a MacroFunc which returns a field of the TraceContext
+// carried by the context of the record, or an empty string if there is no
+// TraceContext.
+func traceMacro(get func(TraceContext) string) MacroFunc {
+	return func(r LogRecord) any {
+		if r.Context == nil {
+			return ""
+		}
+		var tc, _ = TraceFromContext(r.Context)
+		return get(tc)
+	}
+}
+
+// isHex checks if s only contains lowercase hexadecimal digits.
+func isHex(s string) bool {
+	for i := range s {
+		if !('0' <= s[i] && s[i] <= '9') && !('a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZero checks if s only contains zero digits.
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	var tc, err = xylog.ParseTraceParent(traceparent, "congo=t61rcWkgMzE")
+	xycond.ExpectNil(err).Test(t)
+	xycond.ExpectEqual(tc, xylog.TraceContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		TraceFlags: "01",
+		TraceState: "congo=t61rcWkgMzE",
+	}).Test(t)
+	xycond.ExpectEqual(tc.TraceParent(), traceparent).Test(t)
+
+	tc, err = xylog.ParseTraceParent(
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", "")
+	xycond.ExpectNil(err).Test(t)
+	xycond.ExpectEqual(tc.SpanID, "00f067aa0ba902b7").Test(t)
+}
+
+func TestParseTraceParentInvalid(t *testing.T) {
+	var values = []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g",
+	}
+
+	for i := range values {
+		var _, err = xylog.ParseTraceParent(values[i], "")
+		xycond.ExpectError(err, xyerror.ValueError).Test(t)
+	}
+}
+
+func TestTraceFromHeader(t *testing.T) {
+	var header = http.Header{}
+	header.Set("Traceparent", traceparent)
+	header.Set("Tracestate", "congo=t61rcWkgMzE")
+
+	var tc, err = xylog.TraceFromHeader(header)
+	xycond.ExpectNil(err).Test(t)
+	xycond.ExpectEqual(tc.TraceParent(), traceparent).Test(t)
+	xycond.ExpectEqual(tc.TraceState, "congo=t61rcWkgMzE").Test(t)
+
+	_, err = xylog.TraceFromHeader(http.Header{})
+	xycond.ExpectError(err, xyerror.ValueError).Test(t)
+}
+
+func TestTraceFields(t *testing.T) {
+	var tc, _ = xylog.ParseTraceParent(traceparent, "")
+	var ctx = xylog.ContextWithTrace(context.Background(), tc)
+
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.ErrorContext(ctx, "foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo "+
+			"trace_id=4bf92f3577b34da6a3ce929d0e0e4736 "+
+			"span_id=00f067aa0ba902b7 trace_flags=01\n").Test(t)
+	})
+}
+
+func TestTraceMacros(t *testing.T) {
+	var tc, _ = xylog.ParseTraceParent(traceparent, "")
+	var ctx = xylog.ContextWithTrace(context.Background(), tc)
+
+	test.WithHandler(t, func(h *xylog.Handler, w *test.MockWriter) {
+		h.AddMacro("trace", "trace_id")
+		h.AddMacro("span", "span_id")
+		h.AddMacro("flags", "trace_flags")
+
+		h.Handle(xylog.LogRecord{Context: ctx})
+		xycond.ExpectEqual(w.Captured, "trace=4bf92f3577b34da6a3ce929d0e0e4736 "+
+			"span=00f067aa0ba902b7 flags=01\n").Test(t)
+
+		w.Reset()
+		h.Handle(xylog.LogRecord{})
+		xycond.ExpectEqual(w.Captured, "trace= span= flags=\n").Test(t)
+	})
+}
+
+type hexID string
+
+func (id hexID) String() string { return string(id) }
+
+type spanContext struct {
+	valid bool
+}
+
+const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func (sc spanContext) IsValid() bool     { return sc.valid }
+func (sc spanContext) TraceID() hexID    { return traceID }
+func (sc spanContext) SpanID() hexID     { return "00f067aa0ba902b7" }
+func (sc spanContext) TraceFlags() hexID { return "01" }
+
+func TestTraceFromSpanContext(t *testing.T) {
+	var tc, ok = xylog.TraceFromSpanContext[hexID, hexID, hexID](
+		spanContext{valid: true})
+	xycond.ExpectTrue(ok).Test(t)
+	xycond.ExpectEqual(tc.TraceParent(), traceparent).Test(t)
+
+	_, ok = xylog.TraceFromSpanContext[hexID, hexID, hexID](spanContext{})
+	xycond.ExpectFalse(ok).Test(t)
+}
+
+func TestTraceMacrosNotDuplicated(t *testing.T) {
+	var tc, _ = xylog.ParseTraceParent(traceparent, "")
+	var ctx = xylog.ContextWithTrace(context.Background(), tc)
+
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.Handlers()[0].AddMacro("trace_id", "trace_id")
+		logger.ErrorContext(ctx, "foo")
+		xycond.ExpectEqual(w.Captured,
+			"trace_id=4bf92f3577b34da6a3ce929d0e0e4736 messsage=foo "+
+				"span_id=00f067aa0ba902b7 trace_flags=01\n").Test(t)
+	})
+}
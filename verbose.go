@@ -0,0 +1,290 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/xybor-x/xyerror"
+)
+
+// Verbose is returned by Logger.V. It logs with INFO level only if the
+// verbosity level passed to V is enabled at the call site. The zero value
+// never logs.
+type Verbose struct {
+	// lg is nil if the verbosity level is disabled.
+	lg *Logger
+}
+
+// V returns a Verbose which logs only if the level is not greater than the
+// verbosity of the call site. The verbosity is the level of the first vmodule
+// pattern matching the source file or package of the call site, or the global
+// verbosity if no pattern matches, see SetVerbosity and SetVModule.
+//
+// Records are still logged with INFO level, so the level of this Logger must
+// allow INFO too.
+//
+//	logger.V(2).Info("connection opened")
+func (lg *Logger) V(level int) Verbose {
+	if lg.registry.vEnabled(level) {
+		return Verbose{lg: lg}
+	}
+	return Verbose{}
+}
+
+// Enabled returns true if a message logged by this Verbose will be handled.
+func (v Verbose) Enabled() bool {
+	return v.lg != nil && v.lg.isEnabledFor(INFO)
+}
+
+// Info logs default formatting objects with INFO level.
+func (v Verbose) Info(s string) {
+	if v.Enabled() {
+		v.lg.log(nil, INFO, makeField("messsage", s))
+	}
+}
+
+// Infof logs a formatting message with INFO level.
+func (v Verbose) Infof(s string, a ...any) {
+	if v.Enabled() {
+		v.lg.log(nil, INFO, makeField("messsage", fmt.Sprintf(s, a...)))
+	}
+}
+
+// Infow logs a message and loosely typed key-value pairs with INFO level.
+func (v Verbose) Infow(s string, keysAndValues ...any) {
+	if v.Enabled() {
+		var e = v.lg.sugar(s, keysAndValues)
+		defer e.free()
+		v.lg.log(nil, INFO, e.fields...)
+	}
+}
+
+// InfoFn logs a lazily computed message with INFO level. The function is
+// only called if the message is logged by at least one Handler.
+func (v Verbose) InfoFn(f func() string) {
+	if v.Enabled() {
+		v.lg.log(nil, INFO, makeField("messsage", lazyString(f)))
+	}
+}
+
+// SetVerbosity sets the global verbosity of the default Registry, see
+// Logger.V. It is 0 by default.
+func SetVerbosity(level int) {
+	defaultRegistry.SetVerbosity(level)
+}
+
+// Verbosity returns the global verbosity of the default Registry.
+func Verbosity() int {
+	return defaultRegistry.Verbosity()
+}
+
+// SetVModule overrides the global verbosity of the default Registry for source
+// files or packages matching patterns. The spec is a comma-separated list of
+// pattern=level, e.g. "db/*=4,http=2". Use an empty spec to remove all
+// patterns.
+//
+// A pattern is matched by path.Match against the trailing path elements of the
+// source file without the ".go" extension, or of the package import path, as
+// many elements as the pattern has. So "http" matches http.go in any directory
+// and any package named http, while "db/*" matches all files in directories
+// named db. The first matching pattern wins.
+//
+// It is convenient to set it by a command line flag:
+//
+//	flag.Func("vmodule", "per-module verbosity", xylog.SetVModule)
+func SetVModule(spec string) error {
+	return defaultRegistry.SetVModule(spec)
+}
+
+// VModule returns the vmodule spec of the default Registry.
+func VModule() string {
+	return defaultRegistry.VModule()
+}
+
+// SetVerbosity sets the global verbosity of this Registry, see Logger.V.
+func (r *Registry) SetVerbosity(level int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var v = r.loadVModule()
+	r.vmodule.Store(newVModule(level, v.spec, v.rules))
+}
+
+// Verbosity returns the global verbosity of this Registry.
+func (r *Registry) Verbosity() int {
+	return r.loadVModule().level
+}
+
+// SetVModule overrides the global verbosity of this Registry for source files
+// or packages matching patterns. See the package-level SetVModule for details.
+func (r *Registry) SetVModule(spec string) error {
+	var rules, err = parseVModule(spec)
+	if err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var v = r.loadVModule()
+	r.vmodule.Store(newVModule(v.level, spec, rules))
+	return nil
+}
+
+// VModule returns the vmodule spec of this Registry.
+func (r *Registry) VModule() string {
+	return r.loadVModule().spec
+}
+
+// loadVModule returns the current verbosity configuration of this Registry.
+func (r *Registry) loadVModule() *vmodule {
+	return r.vmodule.Load().(*vmodule)
+}
+
+// vEnabled checks whether the verbosity level is enabled at the call site of
+// Logger.V. The verbosity of a call site is only computed once for each
+// configuration.
+func (r *Registry) vEnabled(level int) bool {
+	var v = r.loadVModule()
+	if level <= v.min {
+		return true
+	}
+	if level > v.max {
+		return false
+	}
+
+	var skip = r.loadSettings().skipCall
+	var pcs [1]uintptr
+	if runtime.Callers(skip, pcs[:]) == 0 {
+		return level <= v.level
+	}
+
+	if site, ok := v.sites.Load(pcs[0]); ok {
+		return level <= site.(int)
+	}
+
+	var frame, _ = runtime.CallersFrames(pcs[:]).Next()
+	var module, _ = splitFuncName(frame.Function)
+	var site = v.siteLevel(frame.File, module)
+	v.sites.Store(pcs[0], site)
+	return level <= site
+}
+
+// vmodule is an immutable verbosity configuration of a Registry. A new one is
+// created whenever the configuration changes, so the cached verbosity of call
+// sites never becomes stale.
+type vmodule struct {
+	// level is the global verbosity.
+	level int
+
+	// spec is the original vmodule spec.
+	spec  string
+	rules []vmoduleRule
+
+	// min and max are the lowest and highest verbosity of all call sites, they
+	// let Logger.V decide without finding the call site in most cases.
+	min, max int
+
+	// sites maps program counters of call sites to their verbosity.
+	sites sync.Map
+}
+
+// vmoduleRule is a pattern=level element of a vmodule spec.
+type vmoduleRule struct {
+	pattern string
+	level   int
+}
+
+// newVModule creates a verbosity configuration.
+func newVModule(level int, spec string, rules []vmoduleRule) *vmodule {
+	var v = &vmodule{
+		level: level,
+		spec:  spec,
+		rules: rules,
+		min:   level,
+		max:   level,
+	}
+	for i := range rules {
+		if rules[i].level < v.min {
+			v.min = rules[i].level
+		}
+		if rules[i].level > v.max {
+			v.max = rules[i].level
+		}
+	}
+	return v
+}
+
+// siteLevel returns the verbosity of a call site in the source file and the
+// package.
+func (v *vmodule) siteLevel(file, module string) int {
+	file = strings.TrimSuffix(file, ".go")
+	for _, rule := range v.rules {
+		var n = strings.Count(rule.pattern, "/") + 1
+		if matchTail(rule.pattern, file, n) || matchTail(rule.pattern, module, n) {
+			return rule.level
+		}
+	}
+	return v.level
+}
+
+// matchTail checks whether the pattern matches the last n elements of the
+// slash-separated name.
+func matchTail(pattern, name string, n int) bool {
+	var i = len(name)
+	for ; n > 0 && i >= 0; n-- {
+		i = strings.LastIndexByte(name[:i], '/')
+	}
+	var ok, _ = path.Match(pattern, name[i+1:])
+	return ok
+}
+
+// parseVModule parses a comma-separated list of pattern=level.
+func parseVModule(spec string) ([]vmoduleRule, error) {
+	var rules []vmoduleRule
+	for _, elem := range strings.Split(spec, ",") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" {
+			continue
+		}
+
+		var pattern, value, found = strings.Cut(elem, "=")
+		if !found || pattern == "" {
+			return nil, xyerror.ValueError.Newf("invalid vmodule element %q", elem)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, xyerror.ValueError.Newf("invalid vmodule pattern %q", pattern)
+		}
+
+		var level, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, xyerror.ValueError.Newf("invalid vmodule level %q", value)
+		}
+		rules = append(rules, vmoduleRule{pattern: pattern, level: level})
+	}
+	return rules, nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2022 xybor-x
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package xylog_test
+
+import (
+	"testing"
+
+	"github.com/xybor-x/xycond"
+	"github.com/xybor-x/xyerror"
+	"github.com/xybor-x/xylog"
+	"github.com/xybor-x/xylog/test"
+)
+
+func TestLoggerV(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.SetLevel(xylog.INFO)
+		xylog.SetVerbosity(2)
+		xycond.ExpectEqual(xylog.Verbosity(), 2).Test(t)
+
+		logger.V(2).Info("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+
+		w.Reset()
+		logger.V(3).Info("bar")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+		xycond.ExpectFalse(logger.V(3).Enabled()).Test(t)
+	})
+}
+
+func TestLoggerVMethods(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.SetLevel(xylog.INFO)
+
+		var v = logger.V(0)
+		v.Info("foo")
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+
+		w.Reset()
+		v.Infof("foo %d", 1)
+		xycond.ExpectEqual(w.Captured, "messsage=\"foo 1\"\n").Test(t)
+
+		w.Reset()
+		v.Infow("foo", "bar", 1)
+		xycond.ExpectEqual(w.Captured, "messsage=foo bar=1\n").Test(t)
+
+		w.Reset()
+		v.InfoFn(func() string { return "foo" })
+		xycond.ExpectEqual(w.Captured, "messsage=foo\n").Test(t)
+	})
+}
+
+func TestLoggerVRespectsLevel(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.SetLevel(xylog.WARNING)
+		xycond.ExpectFalse(logger.V(0).Enabled()).Test(t)
+		logger.V(0).Info("foo")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+	})
+}
+
+func TestLoggerVZeroValue(t *testing.T) {
+	var v xylog.Verbose
+	xycond.ExpectFalse(v.Enabled()).Test(t)
+	v.Info("foo")
+}
+
+func TestVModule(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.SetLevel(xylog.INFO)
+
+		var specs = map[string]bool{
+			"verbose_test=3":            true,
+			"*/verbose_*=3":             true,
+			"xylog_test=3":              true,
+			"other=3,verbose_*=2":       false,
+			"verbose_*=2,*_test=3":      false,
+			"verbose_test.go=3":         false,
+			"foo/verbose_test=3":        false,
+			"github.com/*/xylog_test=3": true,
+		}
+
+		for spec, expected := range specs {
+			xycond.ExpectNil(xylog.SetVModule(spec)).Test(t)
+			xycond.ExpectEqual(xylog.VModule(), spec).Test(t)
+
+			w.Reset()
+			logger.V(3).Info("foo")
+			xycond.ExpectEqual(w.Captured != "", expected).Test(t)
+		}
+	})
+}
+
+func TestVModuleLowersVerbosity(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.SetLevel(xylog.INFO)
+		xylog.SetVerbosity(5)
+		xycond.ExpectNil(xylog.SetVModule("verbose_test=1")).Test(t)
+
+		logger.V(2).Info("foo")
+		xycond.ExpectEmpty(w.Captured).Test(t)
+		logger.V(1).Info("foo")
+		xycond.ExpectNotEmpty(w.Captured).Test(t)
+	})
+}
+
+func TestVModuleCacheInvalidation(t *testing.T) {
+	test.WithLogger(t, func(logger *xylog.Logger, w *test.MockWriter) {
+		logger.SetLevel(xylog.INFO)
+
+		var expected = []bool{true, false, true}
+		for i, spec := range []string{"verbose_test=2", "verbose_test=1", ""} {
+			xycond.ExpectNil(xylog.SetVModule(spec)).Test(t)
+			if i == 2 {
+				xylog.SetVerbosity(2)
+			}
+			xycond.ExpectEqual(logger.V(2).Enabled(), expected[i]).Test(t)
+		}
+	})
+}
+
+func TestVModuleError(t *testing.T) {
+	for _, spec := range []string{"foo", "=1", "foo=bar", "[=1"} {
+		xycond.ExpectError(xylog.SetVModule(spec), xyerror.ValueError).Test(t)
+	}
+}
+
+func TestVerbosityRestore(t *testing.T) {
+	var state = xylog.Snapshot()
+	xylog.SetVerbosity(3)
+	xycond.ExpectNil(xylog.SetVModule("foo=1")).Test(t)
+
+	xylog.Restore(state)
+	xycond.ExpectEqual(xylog.Verbosity(), 0).Test(t)
+	xycond.ExpectEmpty(xylog.VModule()).Test(t)
+}